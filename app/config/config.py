@@ -146,6 +146,7 @@ FLAG_CODE = """
         https://flagcdn.com/84x63/{iso_alpha2}.png 3x">
     <img
         src="https://flagcdn.com/28x21/{iso_alpha2}.png"
+        alt=""
         width="28"
         height="21">
 </picture>
@@ -155,11 +156,18 @@ BASE_DIR = Path(__file__).resolve().parent.parent.parent
 
 MINUTE = 60
 HOUR = 60 * MINUTE
+DAY = 24 * HOUR
+
+KILOBYTE = 1024
+MEGABYTE = 1024 * KILOBYTE
 
 LAST_UPDATED = datetime.now(timezone(timedelta(hours=9)))
 
 ALL_DATA = "*"
 
+# 公開URLのドメイン（CANONICAL_HOSTが未設定の場合に使用する）
+SITE_HOST = "gbbinfo-jpn.onrender.com"
+
 
 class ProductionConfig:
     BABEL_DEFAULT_LOCALE = "ja"
@@ -170,16 +178,34 @@ class ProductionConfig:
     CACHE_TYPE = "RedisCache"
     CACHE_REDIS_URL = os.getenv("REDIS_URL")
     DEBUG = False
+    # POSTは小さなJSONのみなので、それを超えるリクエストボディは413で拒否する
+    MAX_CONTENT_LENGTH = 1 * MEGABYTE
+    # 言語・タイムゾーンの設定をブラウザを閉じても保持する
+    PERMANENT_SESSION_LIFETIME = 365 * DAY
+    # 永続セッションのcookieを毎リクエスト再発行しない（設定を変更した時のみ発行する）
+    SESSION_REFRESH_EACH_REQUEST = False
     SECRET_KEY = os.getenv("SECRET_KEY")
     TEMPLATES_AUTO_RELOAD = False
+    # アクセスログの形式 ("json" | "combined")、未設定の場合は出力しない
+    ACCESS_LOG_FORMAT = os.getenv("ACCESS_LOG_FORMAT")
+    # 正規ドメイン（設定時は他のホスト・httpからのアクセスをhttpsの正規ドメインへリダイレクトする）
+    CANONICAL_HOST = os.getenv("CANONICAL_HOST")
+    # メンテナンス中は全ページで503を返す（/healthと静的ファイルを除く）
+    MAINTENANCE_MODE = os.getenv("MAINTENANCE_MODE") == "true"
+    MAINTENANCE_RETRY_AFTER = 10 * MINUTE
+    # アプリの手前にあるリバースプロキシの数（0の場合はX-Forwarded-*を信頼しない）
+    TRUSTED_PROXY_COUNT = int(os.getenv("TRUSTED_PROXY_COUNT", "0"))
 
 
 class PRConfig(ProductionConfig):
     CACHE_REDIS_URL = os.getenv("REDIS_PR_URL")
+    # プレビュー環境は独自のドメインで動作する
+    CANONICAL_HOST = None
 
 
 class TestConfig(ProductionConfig):
     CACHE_TYPE = "null"
+    CANONICAL_HOST = None
     DEBUG = True
     SECRET_KEY = "test"
     TEMPLATES_AUTO_RELOAD = True
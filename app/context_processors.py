@@ -6,7 +6,7 @@ from threading import Thread
 from urllib.parse import urlparse, urlunparse
 
 from dateutil import parser
-from flask import abort, redirect, request, session
+from flask import abort, current_app, redirect, request, session
 from flask_babel import format_datetime
 
 from app.config.config import (
@@ -14,6 +14,7 @@ from app.config.config import (
     HOUR,
     LANGUAGE_CHOICES,
     LAST_UPDATED,
+    SITE_HOST,
     SUPPORTED_LOCALES,
 )
 from app.models.supabase_client import supabase_service
@@ -177,6 +178,9 @@ def is_early_access(year):
 
 
 # MARK: 翻訳対応可否
+COUNTRY_URL_PATTERN = re.compile(r"^(/[^/]+/\d+/country)/[^/]+$")
+
+
 def is_translated(url, language, translated_urls):
     """
     指定されたURLが指定言語で翻訳されているかどうかを判定します。
@@ -197,10 +201,48 @@ def is_translated(url, language, translated_urls):
     if "participant_detail" in url:
         return True
 
+    # 国別ページ（/{lang}/{year}/country/{iso_alpha2}）は共通テンプレートのURLで判定
+    url = COUNTRY_URL_PATTERN.sub(r"\1", url)
+
     # 定数から翻訳されたURLを取得
     return url in translated_urls
 
 
+# MARK: hreflang
+def get_hreflang_urls(path, translated_urls, host):
+    """
+    現在のページの各言語版のURLを、hreflangの値とともに返します。
+
+    Args:
+        path (str): 現在のURLパス（例: "/ja/2025/top"）
+        translated_urls (set): 翻訳済みURLのセット
+        host (str): URLのドメイン（例: "gbbinfo-jpn.onrender.com"）
+
+    Returns:
+        list: (hreflang, url) のタプルリスト。言語付きのURLでない場合は空リスト。
+
+    Note:
+        翻訳されていない言語版は日本語の内容になるため含めません。
+        ただし、現在のページの言語版は翻訳の有無にかかわらず自身を指すために含めます。
+        hreflangはBCP 47形式にするため、"zh_Hans_CN" は "zh-Hans-CN" に変換します。
+    """
+    parts = path.split("/", 2)
+    if len(parts) < 3 or parts[1] not in SUPPORTED_LOCALES:
+        return []
+
+    hreflang_urls = []
+    for lang_code in SUPPORTED_LOCALES:
+        lang_path = f"/{lang_code}/{parts[2]}"
+        if lang_code == parts[1] or is_translated(
+            lang_path, lang_code, translated_urls
+        ):
+            hreflang_urls.append(
+                (lang_code.replace("_", "-"), f"https://{host}{lang_path}")
+            )
+
+    return hreflang_urls
+
+
 # MARK: GBB終了年度
 def is_gbb_ended(year):
     """
@@ -303,6 +345,7 @@ def common_variables(
             - change_language_urls (list): 言語ごとのURLと表示名のタプルリスト
             - language (str): 現在の言語コード
             - is_translated (bool): 現在のページが翻訳済みかどうか
+            - hreflang_urls (list): 各言語版の(hreflang, URL)のリスト
             - last_updated (str): 最終更新日時
             - is_latest_year (bool): 最新年度かどうか
             - is_early_access (bool): 試験公開年度かどうか
@@ -327,6 +370,11 @@ def common_variables(
         "change_language_urls": get_change_language_url(request.url),
         "language": language,
         "is_translated": is_translated(request.path, language, translated_urls),
+        "hreflang_urls": get_hreflang_urls(
+            request.path,
+            translated_urls,
+            current_app.config["CANONICAL_HOST"] or SITE_HOST,
+        ),
         "last_updated": format_datetime(LAST_UPDATED, "full"),
         "is_latest_year": is_latest_year(year),
         "is_early_access": is_early_access(year),
@@ -337,6 +385,25 @@ def common_variables(
     }
 
 
+# MARK: セッション保存
+def save_to_session(key: str, value: str):
+    """
+    値が変わった場合のみセッションに保存し、PERMANENT_SESSION_LIFETIMEの間保持する。
+
+    Args:
+        key (str): セッションのキー（"language"など）。
+        value (str): 保存する値。
+
+    Note:
+        セッションは署名付きcookieのため、書き込むとレスポンスにSet-Cookieが付く。
+        変更がないリクエスト（静的ファイルなど）にSet-Cookieが付くと共有キャッシュに
+        保存されなくなるため、同じ値の再保存はしない。
+    """
+    if session.get(key) != value:
+        session[key] = value
+        session.permanent = True
+
+
 # MARK: 言語設定
 def get_locale():
     """
@@ -348,6 +415,7 @@ def get_locale():
     Note:
         セッションに"language"が設定されていない場合は、リクエストのAccept-Languageヘッダーから
         最適なロケールを選択し、セッションに保存します。該当するロケールがない場合は"ja"をデフォルトとします。
+        セッションは署名付きcookieのため、改ざんされた場合は破棄されAccept-Languageから選び直します。
     """
     # URL の最初のパス要素を優先
     preferred_language = (
@@ -356,7 +424,7 @@ def get_locale():
 
     # URL の言語がサポート済みなら優先
     if preferred_language in SUPPORTED_LOCALES:
-        session["language"] = preferred_language
+        save_to_session("language", preferred_language)
 
     # セッションに言語が設定されていてサポート済みならその言語を使用
     elif session.get("language") in SUPPORTED_LOCALES:
@@ -365,7 +433,7 @@ def get_locale():
     # それ以外はブラウザのAccept-Languageヘッダーから最適な言語を選択
     else:
         best_match = request.accept_languages.best_match(SUPPORTED_LOCALES)
-        session["language"] = best_match if best_match else "ja"
+        save_to_session("language", best_match if best_match else "ja")
 
     return session["language"]
 
@@ -424,6 +492,48 @@ def add_language_and_redirect():
     return redirect(new_url)
 
 
+# MARK: 正規ドメインrd
+def canonical_host_redirect_handler(canonical_host: str, enforce_https: bool):
+    """
+    正規ドメイン以外のホスト、またはhttpでのアクセスを正規ドメインのhttpsへリダイレクトする。
+
+    Args:
+        canonical_host (str): 正規ドメイン（例: "gbbinfo-jpn.onrender.com"）。
+        enforce_https (bool): httpでのアクセスもリダイレクトするか。
+
+    Returns:
+        Response | None: リダイレクトレスポンス。リダイレクト不要の場合はNone。
+
+    Note:
+        - プロキシの背後ではアプリへの接続は常にhttpのため、X-Forwarded-Protoを信頼する
+          （TRUSTED_PROXY_COUNT > 0）場合のみenforce_httpsを有効にする。
+          無効のままhttpsを強制すると、同じURLへのリダイレクトが無限に繰り返される。
+        - ヘルスチェック（/health）はプラットフォームから直接呼ばれるため対象外。
+        - GET/HEAD以外は、メソッドとボディを維持するため308でリダイレクトする。
+    """
+    if request.path == "/health":
+        return None
+
+    if request.host == canonical_host and (
+        request.scheme == "https" or not enforce_https
+    ):
+        return None
+
+    parsed_url = urlparse(request.url)
+    new_url = urlunparse(
+        (
+            "https",
+            canonical_host,
+            parsed_url.path,
+            parsed_url.params,
+            parsed_url.query,
+            parsed_url.fragment,
+        )
+    )
+    code = 301 if request.method in ("GET", "HEAD") else 308
+    return redirect(new_url, code=code)
+
+
 # MARK: 世界地図初期化
 def delete_world_map():
     """
@@ -707,3 +817,53 @@ def initialize_background_tasks(IS_LOCAL):
     if IS_LOCAL:
         Thread(target=delete_world_map).start()
     Thread(target=get_translated_urls).start()
+
+
+# MARK: ウォームアップ
+# participants / result はクエリなしだとデフォルト値へ302になり出場者・結果を取得しないため、
+# リダイレクト先と同じデフォルトのクエリを付ける
+WARM_UP_PATHS = [
+    "/ja/{year}/top",
+    "/ja/{year}/participants?category=Loopstation&ticket_class=all&cancel=show",
+    "/ja/{year}/result?category=Loopstation",
+    "/ja/{year}/japan",
+]
+
+
+def warm_up_pages(app):
+    """
+    最新年度の主要ページを1回ずつレンダリングし、キャッシュを温める。
+
+    Args:
+        app (Flask): Flaskアプリケーションインスタンス。
+
+    Returns:
+        dict: パスごとのステータスコード（例外の場合はNone）。年度を取得できない場合は空。
+
+    Note:
+        - 実際のビューを通すため、Supabaseのデータキャッシュがビューと同じキーで保存され、
+          テンプレートのコンパイル・翻訳カタログの読み込みも済む。
+        - 失敗してもサーバーの起動は止めない（ステータスを表示するのみ）。
+        - 正規ドメインへのリダイレクトで止まらないよう、正規ドメインのhttpsとしてリクエストする。
+    """
+    try:
+        year = get_available_years()[0]
+    except Exception as e:
+        print(f"warm up skipped: {e}", flush=True)
+        return {}
+
+    canonical_host = app.config.get("CANONICAL_HOST")
+    base_url = f"https://{canonical_host}" if canonical_host else None
+
+    status_codes = {}
+    client = app.test_client()
+    for path in WARM_UP_PATHS:
+        path = path.format(year=year)
+        try:
+            response = client.get(path, base_url=base_url)
+            status_codes[path] = response.status_code
+            print(f"warm up {path}: {response.status_code}", flush=True)
+        except Exception as e:
+            status_codes[path] = None
+            print(f"warm up {path}: {e}", flush=True)
+    return status_codes
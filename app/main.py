@@ -1,3 +1,4 @@
+import csv
 import logging
 import os
 from datetime import datetime
@@ -11,14 +12,24 @@ if not hasattr(flask.helpers, "locked_cached_property"):
 
     flask.helpers.locked_cached_property = cached_property
 
+import click
 from flask import (
     Flask,
+    g,
     request,
     send_file,
+    template_rendered,
+)
+from flask_babel import (
+    Babel,
+    _,
+    get_translations,
+    gettext,
+    pgettext,
 )
-from flask_babel import Babel, _
 from flask_caching import Cache
 from flask_sitemapper import Sitemapper
+from werkzeug.middleware.proxy_fix import ProxyFix
 
 from app.config.config import (
     HOUR,
@@ -27,17 +38,33 @@ from app.config.config import (
     TestConfig,
 )
 from app.context_processors import (
+    canonical_host_redirect_handler,
     common_variables,
+    get_available_years,
     get_locale,
     get_variable,
     initialize_background_tasks,
     language_code_redirect_handler,
     valid_locale,
+    warm_up_pages,
+)
+from app.models.supabase_client import CIRCUIT_BREAKER_COOLDOWN, supabase_service
+from app.util.a11y_audit import audit_html
+from app.util.access_log import (
+    ACCESS_LOG_FORMATS,
+    format_access_log,
+    start_request_timer,
+)
+from app.util.translation_report import (
+    get_missing_translations,
+    gettext_with_hit_counter,
+    pgettext_with_hit_counter,
 )
 from app.views import (
     beatboxer_finder,
     beatboxer_web_search,
     common,
+    debug,
     language,
     participant_detail,
     participants,
@@ -83,6 +110,15 @@ else:
     IS_PULL_REQUEST = False
     IS_LOCAL = False
 
+# リバースプロキシの背後では、信頼できるプロキシの数だけX-Forwarded-*を採用する
+# それより手前の値はクライアントが偽装できるため無視される
+if app.config["TRUSTED_PROXY_COUNT"] > 0:
+    app.wsgi_app = ProxyFix(
+        app.wsgi_app,
+        x_for=app.config["TRUSTED_PROXY_COUNT"],
+        x_proto=app.config["TRUSTED_PROXY_COUNT"],
+    )
+
 sitemapper.init_app(app)
 
 
@@ -104,6 +140,15 @@ except Exception:
 babel = Babel(app)
 test = _("test")  # テスト翻訳
 
+# テンプレートの翻訳関数を登録し直し、msgctxtで区別する pgettext も使えるようにする
+# ローカル環境では未翻訳msgidの呼び出し回数を記録する (/debug/i18n/missing で確認)
+app.jinja_env.install_gettext_callables(
+    gettext_with_hit_counter if IS_LOCAL else gettext,
+    lambda s, p, n: get_translations().ungettext(s, p, n),
+    newstyle=True,
+    pgettext=pgettext_with_hit_counter if IS_LOCAL else pgettext,
+)
+
 # バックグラウンド初期化タスクはキャッシュ初期化後に起動
 initialize_background_tasks(IS_LOCAL)
 
@@ -155,10 +200,50 @@ sitemap_variables = build_sitemap_variables()
 ####################################################################
 @app.before_request
 def before_request():
+    start_request_timer()
+    if app.config["CANONICAL_HOST"]:
+        response = canonical_host_redirect_handler(
+            app.config["CANONICAL_HOST"],
+            enforce_https=app.config["TRUSTED_PROXY_COUNT"] > 0,
+        )
+        if response is not None:
+            return response
     get_locale()
+    if app.config["MAINTENANCE_MODE"]:
+        response = common.maintenance_view(app.config["MAINTENANCE_RETRY_AFTER"])
+        if response is not None:
+            return response
     return language_code_redirect_handler()
 
 
+@app.after_request
+def after_request(response):
+    log_format = app.config.get("ACCESS_LOG_FORMAT")
+    if log_format in ACCESS_LOG_FORMATS:
+        print(format_access_log(response, log_format), flush=True)
+
+    # ローカル環境では、レンダリング結果のアクセシビリティ上の問題を表示する
+    if (
+        IS_LOCAL
+        and response.mimetype == "text/html"
+        and not response.direct_passthrough
+    ):
+        template_name = g.get("template_name", "-")
+        for warning in audit_html(response.get_data(as_text=True)):
+            print(f"[a11y] {template_name} ({request.path}): {warning}", flush=True)
+
+    return response
+
+
+def record_template_name(sender, template, context, **extra):
+    """アクセシビリティ監査のログ用に、レンダリングしたテンプレート名を記録する。"""
+    g.template_name = template.name
+
+
+if IS_LOCAL:
+    template_rendered.connect(record_template_name, app)
+
+
 @app.context_processor
 def set_common_variables():
     return common_variables(
@@ -283,14 +368,20 @@ def cancels_view(lang, year):
 @app.route("/<string:lang>/<int:year>/japan")
 def japan(lang, year):
     valid_locale(lang)
-    return participants.participants_country_specific_view(year)
+    return participants.country_participants_view(year, "jp", "common/japan.html")
 
 
 @sitemapper.include(url_variables=sitemap_variables["yearly_pages"])
 @app.route("/<string:lang>/<int:year>/korea")
 def korea(lang, year):
     valid_locale(lang)
-    return participants.participants_country_specific_view(year)
+    return participants.country_participants_view(year, "kr", "common/korea.html")
+
+
+@app.route("/<string:lang>/<int:year>/country/<string:iso_alpha2>")
+def country(lang, year, iso_alpha2):
+    valid_locale(lang)
+    return participants.country_participants_view(year, iso_alpha2)
 
 
 @sitemapper.include(url_variables=sitemap_variables["participant_detail"])
@@ -383,22 +474,121 @@ def health_check():
     return os.getenv("RENDER_GIT_COMMIT", "-")[:7]
 
 
+# MARK: 開発者向け
+@app.route("/debug/i18n/missing")
+def i18n_missing():
+    return debug.i18n_missing_view(IS_LOCAL=IS_LOCAL)
+
+
+# flask --app app.main check-translations [--strict]
+@app.cli.command("check-translations")
+@click.option("--strict", is_flag=True, help="未翻訳がある場合は終了コード1で終了する")
+def check_translations(strict):
+    """言語ごとの翻訳率と未翻訳msgid数を表示する。"""
+    has_untranslated = False
+
+    for language, report in get_missing_translations().items():
+        missing = len(report["missing"])
+        untranslated = len(report["untranslated"])
+        fuzzy = len(report["fuzzy"])
+        click.echo(
+            f"{language}: {report['coverage']:.1%} "
+            f"(missing={missing}, untranslated={untranslated}, fuzzy={fuzzy})"
+        )
+        if missing or untranslated or fuzzy:
+            has_untranslated = True
+
+    if strict and has_untranslated:
+        raise SystemExit(1)
+
+
+# flask --app app.main validate-config
+@app.cli.command("validate-config")
+def validate_config():
+    """必須の環境変数と、組み合わせに問題がある設定を確認する。"""
+    errors = []
+
+    for name in ("SUPABASE_URL", "SUPABASE_ANON_KEY", "SUPABASE_SERVICE_ROLE_KEY"):
+        if not os.getenv(name):
+            errors.append(f"{name} が設定されていません")
+    if not app.config["SECRET_KEY"]:
+        errors.append("SECRET_KEY が設定されていません")
+    if app.config["CACHE_TYPE"] == "RedisCache" and not app.config["CACHE_REDIS_URL"]:
+        errors.append("Redisのキャッシュを使うには REDIS_URL が必要です")
+    if app.config["ACCESS_LOG_FORMAT"] not in (None, *ACCESS_LOG_FORMATS):
+        errors.append(
+            f"ACCESS_LOG_FORMAT は {', '.join(ACCESS_LOG_FORMATS)} のいずれかです"
+        )
+    if app.config["CANONICAL_HOST"] and app.config["TRUSTED_PROXY_COUNT"] == 0:
+        click.echo(
+            "warning: TRUSTED_PROXY_COUNT が0のため、httpからhttpsへのリダイレクトは行いません"
+        )
+
+    for error in errors:
+        click.echo(f"error: {error}")
+    if errors:
+        raise SystemExit(1)
+    click.echo("ok")
+
+
+# flask --app app.main warm-cache
+@app.cli.command("warm-cache")
+def warm_cache():
+    """最新年度の主要ページを表示し、共有キャッシュ（Redis）を温める。"""
+    warm_up_pages(app)
+
+
+# flask --app app.main export-participants [--year 2025] > participants.csv
+@app.cli.command("export-participants")
+@click.option("--year", type=int, help="対象の年度（省略時は最新年度）")
+def export_participants(year):
+    """指定年度の出場者一覧をCSVで標準出力に書き出す。"""
+    if year is None:
+        year = get_available_years()[0]
+
+    participants_data = supabase_service.get_data(
+        table="Participant",
+        columns=["id", "name", "ticket_class", "is_cancelled"],
+        order_by="id",
+        join_tables={
+            "Category": ["name"],
+            "Country": ["iso_alpha2"],
+        },
+        filters={"year": year},
+        raise_error=True,
+    )
+
+    writer = csv.writer(click.get_text_stream("stdout"))
+    writer.writerow(
+        ["id", "name", "category", "ticket_class", "is_cancelled", "iso_alpha2"]
+    )
+    for participant in participants_data:
+        writer.writerow(
+            [
+                participant["id"],
+                participant["name"],
+                participant["Category"]["name"],
+                participant["ticket_class"],
+                participant["is_cancelled"],
+                participant["Country"]["iso_alpha2"],
+            ]
+        )
+
+
 ####################################################################
 # MARK: エラーハンドラー
 ####################################################################
 @app.errorhandler(404)
 def not_found(error):
-    if request.referrer:
-        print(
-            f"404 Not Found: {request.path} | {request.referrer} | {request.user_agent}",
-            flush=True,
-        )
     return common.not_found_page_view()
 
 
 @app.errorhandler(500)
 def internal_server_error(error):
     print(f"500 Internal Server Error: {request.path}", flush=True)
+    # Supabaseが停止中でキャッシュもない場合は、一時的に表示できない旨を返す
+    if supabase_service.is_circuit_open():
+        return common.data_unavailable_view(CIRCUIT_BREAKER_COOLDOWN)
     return common.internal_server_error_view()
 
 
@@ -407,5 +597,9 @@ def main():
 
     Returns:
         Flask: Flaskアプリケーションインスタンス
+
+    Note:
+        waitressがリクエストを受け付ける前に、主要ページを1回ずつ表示してキャッシュを温める。
     """
+    warm_up_pages(app)
     return app
@@ -6,19 +6,65 @@ SupabaseとのAPIやりとり用
 import hashlib
 import json
 import os
+import random
+from contextlib import contextmanager
+from threading import Lock, Thread
+from time import monotonic, perf_counter, sleep, time
 from typing import Optional
 
+import httpx
 import pandas as pd
 from dotenv import load_dotenv
 from postgrest.exceptions import APIError
-from supabase import Client, create_client
+from supabase import Client, ClientOptions, create_client
 
-from app.config.config import ALL_DATA, MINUTE
+from app.config.config import ALL_DATA, HOUR, MINUTE
+from app.util.access_log import add_upstream_time
 from app.util.filter_eq import Operator
 
 # ここに書かないと読み込みタイミングが遅くなってエラーになる
 load_dotenv()
 
+# 有効期限切れ後も古いデータを返してよい期間（stale-while-revalidate）
+STALE_TIMEOUT = 1 * HOUR
+
+# Supabaseへの1回の問い合わせで待つ最大秒数（デフォルトの120秒では接続を長く占有してしまう）
+SUPABASE_QUERY_TIMEOUT = 10
+
+# 一時的なエラー（ゲートウェイエラー・接続失敗）の場合の再試行回数と待ち時間の基準
+SUPABASE_MAX_RETRIES = 2
+SUPABASE_RETRY_BASE_DELAY = 0.2  # 秒
+# ゲートウェイがJSON以外を返した場合、postgrestはAPIError.codeにHTTPステータス（int）を入れる
+RETRYABLE_STATUS_CODES = {"502", "503", "504"}
+
+# 連続でこの回数失敗したら、一定時間Supabaseへの問い合わせを止める（サーキットブレーカー）
+CIRCUIT_BREAKER_THRESHOLD = 5
+CIRCUIT_BREAKER_COOLDOWN = 30  # 秒
+# サーキットブレーカーで障害として数えるエラーコード（5xxとstatement timeout）
+UNAVAILABLE_ERROR_CODES = RETRYABLE_STATUS_CODES | {"500", "57014"}
+
+# 同じクエリを待っていたリクエストが、直前の失敗を共有して問い合わせずに失敗する期間
+FAILED_QUERY_HOLD = 5  # 秒
+
+
+class SupabaseUnavailableError(Exception):
+    """サーキットブレーカーが開いており、Supabaseへ問い合わせなかった場合の例外"""
+
+
+def is_unavailable_error(error: Exception) -> bool:
+    """
+    Supabaseに到達できない・応答できない状態を示すエラーかを判定する。
+
+    Args:
+        error (Exception): クエリの実行時に発生した例外。
+
+    Returns:
+        bool: 接続失敗・タイムアウト・5xxの場合はTrue。クエリの誤りなどはFalse。
+    """
+    if isinstance(error, httpx.TransportError):
+        return True
+    return isinstance(error, APIError) and str(error.code) in UNAVAILABLE_ERROR_CODES
+
 
 class SupabaseService:
     """Supabaseとのやり取りを管理するサービスクラス
@@ -28,6 +74,12 @@ class SupabaseService:
 
     Attributes:
         _client (Optional[Client]): Supabaseクライアントのインスタンス（管理者権限用）
+        _refreshing_keys (set[str]): バックグラウンドで更新中のキャッシュキー
+        _key_locks (dict[str, list]): 同じクエリの同時実行を防ぐためのキャッシュキーごとの
+            [ロック, 待機中のリクエスト数, 直前に問い合わせ不能で失敗した時刻]。
+            誰も使っていないキーは削除する
+        _consecutive_failures (int): Supabaseへの問い合わせが連続で失敗した回数
+        _circuit_opened_at (Optional[float]): サーキットブレーカーが開いた時刻（閉じている場合はNone）
     """
 
     def __init__(self):
@@ -53,6 +105,11 @@ class SupabaseService:
 
         self._read_only_client: Optional[Client] = None
         self._admin_client: Optional[Client] = None
+        self._refreshing_keys: set[str] = set()
+        self._key_locks: dict[str, list] = {}
+        self._consecutive_failures = 0
+        self._circuit_opened_at: Optional[float] = None
+        self._lock = Lock()
 
     # MARK: read only
     @property
@@ -69,7 +126,11 @@ class SupabaseService:
         if self._read_only_client is None:
             supabase_url = os.getenv("SUPABASE_URL")
             supabase_key = os.getenv("SUPABASE_ANON_KEY")
-            self._read_only_client = create_client(supabase_url, supabase_key)
+            self._read_only_client = create_client(
+                supabase_url,
+                supabase_key,
+                options=ClientOptions(postgrest_client_timeout=SUPABASE_QUERY_TIMEOUT),
+            )
 
         return self._read_only_client
 
@@ -94,7 +155,11 @@ class SupabaseService:
                     "環境変数 SUPABASE_URL および SUPABASE_SERVICE_ROLE_KEY が設定されている必要があります"
                 )
 
-            self._admin_client = create_client(supabase_url, supabase_key)
+            self._admin_client = create_client(
+                supabase_url,
+                supabase_key,
+                options=ClientOptions(postgrest_client_timeout=SUPABASE_QUERY_TIMEOUT),
+            )
 
         return self._admin_client
 
@@ -192,7 +257,8 @@ class SupabaseService:
         # JSON文字列に変換してハッシュ化
         params_str = json.dumps(params, sort_keys=True, ensure_ascii=False)
         cache_key = hashlib.md5(params_str.encode("utf-8")).hexdigest()
-        return f"supabase_data_{cache_key}"
+        # 値はデータと有効期限をまとめた辞書（_set_cache参照）。データのみの旧形式と区別する
+        return f"supabase_entry_{cache_key}"
 
     # MARK: get
     def get_data(
@@ -225,6 +291,14 @@ class SupabaseService:
 
         Raises:
             ValueError: テーブル名が指定されていない場合や、取得に失敗した場合に発生。
+
+        Note:
+            有効期限切れ後もSTALE_TIMEOUTの間は古いデータを即座に返し、
+            バックグラウンドで1回だけ再取得してキャッシュを更新する。
+            キャッシュがない状態で同じクエリが同時に来た場合、Supabaseへの問い合わせは1回にまとめる。
+            その問い合わせが接続失敗・タイムアウトなどで失敗した場合、待っていたリクエストも
+            FAILED_QUERY_HOLD秒の間は問い合わせずに失敗する。
+            Supabaseが連続で失敗している間は、問い合わせずに取得失敗として扱う（_execute_query参照）。
         """
         # ここに書かないと循環インポートになる
         from app.main import flask_cache
@@ -240,12 +314,218 @@ class SupabaseService:
         )
 
         # キャッシュから取得を試行 あるなら返す
-        cached_data = flask_cache.get(cache_key)
-        if cached_data is not None:
+        cached_entry = flask_cache.get(cache_key)
+        if cached_entry is not None:
+            # 有効期限切れの場合は古いデータを返しつつ、裏で更新する
+            if time() >= cached_entry["fresh_until"]:
+                query = self._build_query(
+                    table, columns, order_by, join_tables, filters, **filters_eq
+                )
+                self._refresh_in_background(cache_key, query, timeout)
+
             if pandas:
-                return pd.DataFrame(cached_data, index=None)
-            return cached_data
+                return pd.DataFrame(cached_entry["data"], index=None)
+            return cached_entry["data"]
+
+        # 同じクエリの同時実行は1回にまとめる
+        with self._hold_key_lock(cache_key) as key_lock:
+            # 待っている間に他のリクエストが取得していれば、それを返す
+            cached_entry = flask_cache.get(cache_key)
+            if cached_entry is not None:
+                cached_data = cached_entry["data"]
+            else:
+                query = self._build_query(
+                    table, columns, order_by, join_tables, filters, **filters_eq
+                )
+
+                # 用意したqueryを実行し、データを取得
+                started_at = perf_counter()
+                try:
+                    # 待っている間に同じクエリが問い合わせ不能で失敗していれば、
+                    # 1件ずつタイムアウトまで待たず、問い合わせずに失敗させる
+                    failed_at = key_lock[2]
+                    if (
+                        failed_at is not None
+                        and monotonic() - failed_at < FAILED_QUERY_HOLD
+                    ):
+                        raise SupabaseUnavailableError("同じクエリが直前に失敗しました")
+                    response = self._execute_query(query)
+                except Exception as e:
+                    if is_unavailable_error(e):
+                        key_lock[2] = monotonic()
+                    print(f"SupabaseClient get_data error: {e}", flush=True)
+                    if raise_error:
+                        raise e
+                    if pandas:
+                        return pd.DataFrame([], index=None)
+                    return []
+                finally:
+                    # アクセスログ用に待ち時間を記録
+                    add_upstream_time(perf_counter() - started_at)
+
+                # 取得したデータをキャッシュに保存
+                self._set_cache(cache_key, response.data, timeout)
+                cached_data = response.data
+
+        if pandas:
+            return pd.DataFrame(cached_data, index=None)
+        return cached_data
+
+    # MARK: circuit breaker
+    def _execute_query(self, query):
+        """
+        サーキットブレーカーを通してクエリを実行する内部メソッド。
+
+        Args:
+            query: 実行するクエリオブジェクト。
 
+        Returns:
+            クエリの実行結果。
+
+        Raises:
+            SupabaseUnavailableError: サーキットブレーカーが開いている場合。
+            Exception: クエリの実行に失敗した場合。
+
+        Note:
+            - CIRCUIT_BREAKER_THRESHOLD回連続で失敗すると開き、問い合わせずに即座に失敗させる。
+            - 数えるのは接続失敗・タイムアウト・5xxのみ（is_unavailable_error）。
+              クエリの誤りなど、Supabaseが応答したエラーは成功と同じく扱う。
+            - CIRCUIT_BREAKER_COOLDOWN秒経過後、1回だけ試しに問い合わせる（半開）。
+              成功すれば閉じ、失敗すれば再度開く。
+        """
+        with self._lock:
+            if self._circuit_opened_at is not None:
+                if monotonic() - self._circuit_opened_at < CIRCUIT_BREAKER_COOLDOWN:
+                    raise SupabaseUnavailableError("Supabaseへの問い合わせを停止中です")
+
+                # 試しに問い合わせる間、他のリクエストは引き続き即座に失敗させる
+                self._circuit_opened_at = monotonic()
+
+        try:
+            response = self._execute_with_retry(query)
+        except Exception as e:
+            if is_unavailable_error(e):
+                self._record_failure()
+            else:
+                self._record_success()
+            raise
+
+        self._record_success()
+        return response
+
+    def is_circuit_open(self) -> bool:
+        """
+        サーキットブレーカーが開いている（Supabaseへの問い合わせを止めている）かを返す。
+
+        Returns:
+            bool: 開いている、または試しに問い合わせている（半開）場合はTrue。
+        """
+        with self._lock:
+            return self._circuit_opened_at is not None
+
+    def _record_failure(self):
+        """Supabaseへの問い合わせ失敗を記録し、連続で失敗していればサーキットブレーカーを開く。"""
+        with self._lock:
+            self._consecutive_failures += 1
+            if self._consecutive_failures >= CIRCUIT_BREAKER_THRESHOLD:
+                if self._circuit_opened_at is None:
+                    print("SupabaseClient circuit breaker opened", flush=True)
+                self._circuit_opened_at = monotonic()
+
+    def _record_success(self):
+        """Supabaseが応答したことを記録し、サーキットブレーカーを閉じる。"""
+        with self._lock:
+            if self._circuit_opened_at is not None:
+                print("SupabaseClient circuit breaker closed", flush=True)
+            self._consecutive_failures = 0
+            self._circuit_opened_at = None
+
+    # MARK: retry
+    def _execute_with_retry(self, query):
+        """
+        一時的なエラーの場合に再試行しながらクエリを実行する内部メソッド。
+
+        Args:
+            query: 実行するクエリオブジェクト。
+
+        Returns:
+            クエリの実行結果。
+
+        Note:
+            - 取得（SELECT）のみを行うため、再試行しても副作用はない。
+            - 502/503/504と接続失敗のみ再試行し、タイムアウトやクエリの誤りは再試行しない。
+            - 待ち時間は指数バックオフ + full jitter（0〜基準×2^回数秒のランダム）。
+        """
+        for attempt in range(SUPABASE_MAX_RETRIES + 1):
+            try:
+                return query.execute()
+            except (APIError, httpx.ConnectError) as e:
+                is_retryable = isinstance(e, httpx.ConnectError) or (
+                    str(e.code) in RETRYABLE_STATUS_CODES
+                )
+                if not is_retryable or attempt == SUPABASE_MAX_RETRIES:
+                    raise
+
+                print(
+                    f"SupabaseClient retry {attempt + 1}/{SUPABASE_MAX_RETRIES}: {e}",
+                    flush=True,
+                )
+                sleep(random.uniform(0, SUPABASE_RETRY_BASE_DELAY * 2**attempt))
+
+    # MARK: key lock
+    @contextmanager
+    def _hold_key_lock(self, cache_key: str):
+        """
+        キャッシュキーごとのロックを取得し、ブロックを抜けたら解放する内部メソッド。
+
+        Args:
+            cache_key (str): キャッシュキー。
+
+        Yields:
+            list: [ロック, 待機中のリクエスト数, 直前に問い合わせ不能で失敗した時刻]。
+                ロックを持っている間は失敗時刻を読み書きしてよい。
+
+        Note:
+            キャッシュキーにはURL由来の値（参加者IDなど）が含まれるため、
+            待機中のリクエストがいなくなったロックは削除し、_key_locksが増え続けないようにする。
+        """
+        with self._lock:
+            entry = self._key_locks.setdefault(cache_key, [Lock(), 0, None])
+            entry[1] += 1
+
+        try:
+            with entry[0]:
+                yield entry
+        finally:
+            with self._lock:
+                entry[1] -= 1
+                if entry[1] == 0:
+                    del self._key_locks[cache_key]
+
+    # MARK: build query
+    def _build_query(
+        self,
+        table: str,
+        columns: Optional[list] = None,
+        order_by: str = None,
+        join_tables: Optional[dict] = None,
+        filters: Optional[dict] = None,
+        **filters_eq,
+    ):
+        """
+        get_dataの引数からSupabaseのクエリを組み立てる内部メソッド。
+
+        Args:
+            table (str): 取得対象のテーブル名。
+            columns (Optional[list], optional): 取得するカラム名のリスト。
+            order_by (str, optional): 並び替えに使用するカラム名。
+            join_tables (Optional[dict], optional): JOINするテーブルとそのカラムの指定。
+            filters (Optional[dict], optional): フィルタ条件を指定する辞書。
+            **filters_eq: その他、等価条件によるフィルタをキーワード引数で指定。
+
+        Returns:
+            実行前のクエリオブジェクト
+        """
         # カラム指定の構築
         if join_tables:
             # JOINありの場合
@@ -316,23 +596,62 @@ class SupabaseService:
             else:
                 query = query.order(order_by)
 
-        # 用意したqueryを実行し、データを取得
-        try:
-            response = query.execute()
-        except Exception as e:
-            print(f"SupabaseClient get_data error: {e}", flush=True)
-            if raise_error:
-                raise e
-            if pandas:
-                return pd.DataFrame([], index=None)
-            return []
+        return query
 
-        # 取得したデータをキャッシュに保存
-        flask_cache.set(cache_key, response.data, timeout=timeout)
+    # MARK: cache set
+    def _set_cache(self, cache_key: str, data, timeout: int):
+        """
+        取得したデータをキャッシュに保存する内部メソッド。
 
-        if pandas:
-            return pd.DataFrame(response.data, index=None)
-        return response.data
+        Args:
+            cache_key (str): キャッシュキー。
+            data: 保存するデータ。
+            timeout (int): キャッシュの有効期限。
+
+        Note:
+            データと有効期限（fresh_until、UNIX時刻）を1つの値にまとめ、
+            有効期限 + STALE_TIMEOUT の間保持する。
+            キャッシュヒット時の取得を1回にし、データと有効期限が別々に消えないようにするため。
+        """
+        # ここに書かないと循環インポートになる
+        from app.main import flask_cache
+
+        flask_cache.set(
+            cache_key,
+            {"data": data, "fresh_until": time() + timeout},
+            timeout=timeout + STALE_TIMEOUT,
+        )
+
+    # MARK: refresh
+    def _refresh_in_background(self, cache_key: str, query, timeout: int):
+        """
+        有効期限切れのキャッシュをバックグラウンドで更新する内部メソッド。
+
+        Args:
+            cache_key (str): 更新対象のキャッシュキー。
+            query: 実行するクエリオブジェクト。
+            timeout (int): キャッシュの有効期限。
+
+        Note:
+            同じキーの更新がすでに実行中の場合は何もしない。
+            更新に失敗した場合は古いデータをそのまま使い続ける。
+        """
+        with self._lock:
+            if cache_key in self._refreshing_keys:
+                return
+            self._refreshing_keys.add(cache_key)
+
+        def refresh():
+            try:
+                response = self._execute_query(query)
+                self._set_cache(cache_key, response.data, timeout)
+            except Exception as e:
+                print(f"SupabaseClient refresh error: {e}", flush=True)
+            finally:
+                with self._lock:
+                    self._refreshing_keys.discard(cache_key)
+
+        Thread(target=refresh, daemon=True).start()
 
     # MARK: ---
 
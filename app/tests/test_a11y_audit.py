@@ -0,0 +1,49 @@
+"""
+アクセシビリティ監査のテストモジュール
+
+python -m pytest app/tests/test_a11y_audit.py -v
+"""
+
+import unittest
+
+from app.util.a11y_audit import audit_html
+
+
+class A11yAuditTestCase(unittest.TestCase):
+    """audit_htmlのテストケース"""
+
+    def test_no_warnings(self):
+        """問題のないHTMLでは警告が出ないことを確認"""
+        html = """
+        <html lang="ja">
+          <h1>GBB 2025</h1>
+          <h2>出場者</h2>
+          <img src="/static/icon.png" alt="">
+          <h2>ルール</h2>
+          <h3>審査員</h3>
+        </html>
+        """
+        self.assertEqual(audit_html(html), [])
+
+    def test_warnings(self):
+        """lang属性・alt属性の欠落と見出しレベルの飛びを検出することを確認"""
+        html = """
+        <html>
+          <h1>GBB 2025</h1>
+          <h3>出場者</h3>
+          <img src="https://flagcdn.com/20x15/jp.png">
+          <img src="https://flagcdn.com/20x15/jp.png">
+        </html>
+        """
+        self.assertEqual(
+            audit_html(html),
+            [
+                "<html>にlang属性がありません",
+                "見出しレベルが飛んでいます: h1 → h3",
+                "<img>にalt属性がありません: https://flagcdn.com/20x15/jp.png (×2)",
+            ],
+        )
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,71 @@
+"""
+アクセスログ整形のテストモジュール
+
+python -m pytest app/tests/test_access_log.py -v
+"""
+
+import json
+import re
+import unittest
+
+from flask import Flask, Response
+
+from app.util.access_log import (
+    add_upstream_time,
+    format_access_log,
+    start_request_timer,
+)
+
+app = Flask(__name__)
+
+
+class AccessLogTestCase(unittest.TestCase):
+    """format_access_logのテストケース"""
+
+    def test_combined_format(self):
+        """Apache Combined Log Format で出力されることを確認"""
+        with app.test_request_context(
+            "/ja/2025/participants?category=Solo",
+            headers={"Referer": "https://example.com/", "User-Agent": "pytest"},
+            environ_base={"REMOTE_ADDR": "203.0.113.1"},
+        ):
+            start_request_timer()
+            response = Response("hello", status=200)
+
+            line = format_access_log(response, "combined")
+
+        self.assertRegex(
+            line,
+            re.compile(
+                r'^203\.0\.113\.1 - - \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] '
+                r'"GET /ja/2025/participants\?category=Solo HTTP/1\.1" 200 5 '
+                r'"https://example\.com/" "pytest"$'
+            ),
+        )
+
+    def test_json_format_with_upstream_breakdown(self):
+        """JSON形式で外部APIの待ち時間の内訳が出力されることを確認"""
+        with app.test_request_context("/ja/2025/top"):
+            start_request_timer()
+            add_upstream_time(0.25)
+            add_upstream_time(0.5)
+            response = Response("hello", status=404)
+
+            log = json.loads(format_access_log(response, "json"))
+
+        self.assertEqual(log["method"], "GET")
+        self.assertEqual(log["path"], "/ja/2025/top")
+        self.assertEqual(log["status"], 404)
+        self.assertEqual(log["bytes"], 5)
+        self.assertEqual(log["referer"], "-")
+        self.assertEqual(log["upstream_ms"], 750.0)
+        self.assertEqual(log["upstream_calls"], 2)
+        self.assertGreaterEqual(log["duration_ms"], 0)
+
+    def test_add_upstream_time_outside_request(self):
+        """リクエスト外から呼ばれても例外にならないことを確認"""
+        add_upstream_time(1.0)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -1506,6 +1506,20 @@ class BeatboxerTavilySearchTestCase(unittest.TestCase):
                     ]
                 )
 
+            # country specific (/japan or /korea) の国データ
+            if table == "Country":
+                iso_alpha2 = filters.get("iso_alpha2")
+                iso_code = {"jp": 392, "kr": 410}.get(iso_alpha2)
+                if iso_code is None:
+                    return []
+                return [
+                    {
+                        "iso_code": iso_code,
+                        "names": {"ja": iso_alpha2, "en": iso_alpha2},
+                        "iso_alpha2": iso_alpha2,
+                    }
+                ]
+
             if table == "Participant":
                 # country specific (/japan or /korea)
                 if "iso_code" in filters:
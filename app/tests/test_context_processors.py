@@ -23,6 +23,7 @@ with patch("app.context_processors.supabase_service") as mock_supabase:
     mock_supabase.get_data.side_effect = mock_get_data
     from app.context_processors import (
         get_available_years,
+        get_hreflang_urls,
         is_early_access,
         is_latest_year,
         is_translated,
@@ -107,6 +108,146 @@ class ContextProcessorsTestCase(unittest.TestCase):
         self.assertTrue(is_translated("/test", "en", translated_urls))
         self.assertFalse(is_translated("/not-translated", "en", translated_urls))
 
+        # 国別ページは国コードを除いたURLで判定
+        translated_urls = {"/en/2025/country"}
+        self.assertTrue(is_translated("/en/2025/country/jp", "en", translated_urls))
+        self.assertFalse(is_translated("/ko/2025/country/jp", "ko", translated_urls))
+
+    def test_get_hreflang_urls(self):
+        """hreflang用の各言語版URL生成のテスト"""
+        translated_urls = {"/en/2025/top", "/zh_Hant_TW/2025/top"}
+
+        host = "gbbinfo.example.com"
+
+        self.assertEqual(
+            get_hreflang_urls("/ko/2025/top", translated_urls, host),
+            [
+                ("ja", "https://gbbinfo.example.com/ja/2025/top"),
+                ("en", "https://gbbinfo.example.com/en/2025/top"),
+                ("zh-Hant-TW", "https://gbbinfo.example.com/zh_Hant_TW/2025/top"),
+            ],
+        )
+
+        # 未翻訳の言語版でも、現在のページ自身は含める
+        self.assertEqual(
+            get_hreflang_urls("/ko/2025/rule", translated_urls, host),
+            [
+                ("ja", "https://gbbinfo.example.com/ja/2025/rule"),
+                ("ko", "https://gbbinfo.example.com/ko/2025/rule"),
+            ],
+        )
+
+        # 国別ページは共通テンプレート（common/country.html）の翻訳で判定する
+        self.assertEqual(
+            get_hreflang_urls(
+                "/ja/2025/country/fr", translated_urls | {"/en/2025/country"}, host
+            ),
+            [
+                ("ja", "https://gbbinfo.example.com/ja/2025/country/fr"),
+                ("en", "https://gbbinfo.example.com/en/2025/country/fr"),
+            ],
+        )
+
+        # 言語付きでないURLは対象外
+        self.assertEqual(get_hreflang_urls("/sitemap.xml", translated_urls, host), [])
+        self.assertEqual(get_hreflang_urls("/", translated_urls, host), [])
+
+    def test_get_locale_persists_session(self):
+        """言語設定が永続的な署名付きcookieに保存されることのテスト"""
+        from flask import session
+
+        from app.context_processors import get_locale
+
+        with app.test_request_context(
+            "/", headers={"Accept-Language": "ko-KR,ko;q=0.9"}
+        ):
+            self.assertEqual(get_locale(), "ko")
+            self.assertEqual(session["language"], "ko")
+            self.assertTrue(session.permanent)
+
+    def test_session_cookie_only_set_on_change(self):
+        """設定が変わらないリクエストにはSet-Cookieが付かないことのテスト"""
+        client = app.test_client()
+        headers = {"Accept-Language": "ko-KR,ko;q=0.9"}
+
+        response = client.get("/robots.txt", headers=headers)
+        self.assertIn("Set-Cookie", response.headers)
+
+        response = client.get("/robots.txt", headers=headers)
+        self.assertNotIn("Set-Cookie", response.headers)
+
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.get_available_years")
+    @patch("app.views.participants.get_available_years")
+    @patch("app.views.result.supabase_service")
+    @patch("app.views.participants.supabase_service")
+    def test_warm_up_pages(
+        self,
+        mock_participants_supabase,
+        mock_result_supabase,
+        mock_participants_get_available_years,
+        mock_get_available_years,
+        mock_get_translated_urls,
+        mock_is_gbb_ended,
+    ):
+        """最新年度の主要ページがリダイレクトされずに200で表示されることのテスト"""
+        import pandas as pd
+
+        from app.context_processors import WARM_UP_PATHS, warm_up_pages
+
+        def mock_get_data(*args, **kwargs):
+            table = kwargs.get("table")
+            if table == "Year":
+                return pd.DataFrame([{"categories": [1]}])
+            if table == "Category":
+                return pd.DataFrame(
+                    [{"id": 1, "name": "Loopstation", "is_team": False}]
+                )
+            if table == "Country":
+                return [{"iso_code": 392, "names": {"ja": "日本"}, "iso_alpha2": "jp"}]
+            return []
+
+        mock_participants_supabase.get_data.side_effect = mock_get_data
+        mock_result_supabase.get_data.side_effect = mock_get_data
+        mock_participants_get_available_years.return_value = [2025]
+        mock_get_available_years.return_value = [2025]
+        mock_get_translated_urls.return_value = set()
+        mock_is_gbb_ended.return_value = True
+
+        status_codes = warm_up_pages(app)
+
+        self.assertEqual(len(status_codes), len(WARM_UP_PATHS))
+        self.assertTrue(all(path.startswith("/ja/2025/") for path in status_codes))
+        self.assertEqual(set(status_codes.values()), {200})
+
+        # 出場者・結果のデータまで取得されている
+        requested_tables = [
+            call.kwargs["table"]
+            for call in mock_participants_supabase.get_data.call_args_list
+            + mock_result_supabase.get_data.call_args_list
+        ]
+        self.assertIn("Participant", requested_tables)
+        self.assertIn("TournamentResult", requested_tables)
+
+    @patch("app.context_processors.get_available_years")
+    def test_warm_up_pages_base_url(self, mock_get_available_years):
+        """正規ドメインへのリダイレクトで止まらないよう、正規ドメインのhttpsで送ることのテスト"""
+        from app.context_processors import warm_up_pages
+
+        mock_get_available_years.return_value = [2026, 2025]
+        mock_app = MagicMock()
+        mock_app.config = {"CANONICAL_HOST": "gbbinfo-jpn.onrender.com"}
+        mock_client = mock_app.test_client.return_value
+        mock_client.get.return_value.status_code = 200
+
+        warm_up_pages(mock_app)
+
+        for call in mock_client.get.call_args_list:
+            self.assertEqual(
+                call.kwargs["base_url"], "https://gbbinfo-jpn.onrender.com"
+            )
+
     @patch("app.context_processors.BASE_DIR")
     def test_get_others_content(self, mock_base_dir):
         """'Others'カテゴリのコンテンツ取得テスト"""
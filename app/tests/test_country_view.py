@@ -0,0 +1,286 @@
+"""
+Flask アプリケーションの国別出場者ページ（/<year>/country/<iso_alpha2>）のテストモジュール
+
+python -m pytest app/tests/test_country_view.py -v
+"""
+
+import unittest
+from unittest.mock import patch
+
+# Supabaseサービスをモックしてからapp.mainをインポート
+with patch("app.context_processors.supabase_service") as mock_supabase:
+    # get_available_years()とget_participant_id()のためのモックデータ
+    def mock_get_data(*args, **kwargs):
+        table = kwargs.get("table")
+        if table == "Year":
+            return [{"year": 2025}]
+        elif table == "Participant":
+            return [{"id": 1, "name": "Test", "Category": {"is_team": False}}]
+        elif table == "ParticipantMember":
+            return [{"id": 2}]
+        return []
+
+    mock_supabase.get_data.side_effect = mock_get_data
+    from app.main import app
+
+
+def create_country_names_mock():
+    """Country.namesのモックを生成する"""
+    return {"ja": "フランス", "en": "France", "ko": "프랑스"}
+
+
+def participants_get_data_side_effect(*args, **kwargs):
+    """participantsビュー内のSupabase呼び出しモック"""
+    table = kwargs.get("table")
+    filters = kwargs.get("filters", {})
+
+    if table == "Country":
+        if filters.get("iso_alpha2") == "fr":
+            return [
+                {
+                    "iso_code": 250,
+                    "names": create_country_names_mock(),
+                    "iso_alpha2": "fr",
+                }
+            ]
+        return []
+
+    if table == "Participant":
+        if filters.get("iso_code") == 250:
+            return [
+                {
+                    "id": 1,
+                    "name": "alpha",
+                    "category": 1,
+                    "ticket_class": "Wildcard 1 (2025)",
+                    "is_cancelled": False,
+                    "iso_code": 250,
+                    "Category": {"id": 1, "name": "Solo", "is_team": False},
+                    "Country": {"iso_code": 250, "iso_alpha2": "fr"},
+                    "ParticipantMember": [],
+                },
+                {
+                    "id": 2,
+                    "name": "beta",
+                    "category": 1,
+                    "ticket_class": "GBB Seed",
+                    "is_cancelled": True,
+                    "iso_code": 250,
+                    "Category": {"id": 1, "name": "Solo", "is_team": False},
+                    "Country": {"iso_code": 250, "iso_alpha2": "fr"},
+                    "ParticipantMember": [],
+                },
+            ]
+        # 多国籍チームデータ（iso_code=9999）
+        if filters.get("iso_code") == 9999:
+            return [
+                {
+                    "id": 100,
+                    "name": "multinational team",
+                    "category": 2,
+                    "ticket_class": "GBB Seed",
+                    "is_cancelled": False,
+                    "Category": {"id": 2, "name": "Tag Team", "is_team": True},
+                    "ParticipantMember": [
+                        {
+                            "name": "Member from France",
+                            "iso_code": 250,
+                            "Country": {"iso_alpha2": "fr"},
+                        },
+                        {
+                            "name": "Member from Japan",
+                            "iso_code": 392,
+                            "Country": {"iso_alpha2": "jp"},
+                        },
+                    ],
+                    "Country": {"iso_code": 9999},
+                }
+            ]
+    return []
+
+
+class CountryViewTestCase(unittest.TestCase):
+    """country_participants_viewのテストケース"""
+
+    def setUp(self):
+        """テストの前準備"""
+        app.config["TESTING"] = True
+        app.config["WTF_CSRF_ENABLED"] = False
+        self.client = app.test_client()
+        self.app_context = app.app_context()
+        self.app_context.push()
+        # Supabaseモック側の Year は 2025 固定のため、テストも 2025 に揃える
+        self.year = 2025
+
+    def tearDown(self):
+        """テスト後のクリーンアップ"""
+        self.app_context.pop()
+
+    @patch("app.views.participants.supabase_service")
+    @patch("app.views.participants.get_available_years")
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    def test_country_view_normal_case(
+        self,
+        mock_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+        mock_participants_get_available_years,
+        mock_supabase,
+    ):
+        """国別ページの正常系テスト（多国籍チームも含まれる）"""
+        mock_get_available_years.return_value = [2025]
+        mock_participants_get_available_years.return_value = [2025]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+        mock_supabase.get_data.side_effect = participants_get_data_side_effect
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "ja"
+
+        resp = self.client.get(f"/ja/{self.year}/country/FR")
+        self.assertEqual(resp.status_code, 200)
+
+        response_data = resp.get_data(as_text=True)
+        self.assertIn("フランス", response_data)
+        self.assertIn("ALPHA", response_data)  # 大文字変換確認
+        self.assertIn("BETA", response_data)
+        self.assertIn("MULTINATIONAL TEAM", response_data)
+        self.assertIn(f"/{self.year}/country/fr", response_data)  # canonical
+
+        # 辞退者は下にソートされる
+        self.assertLess(response_data.index("ALPHA"), response_data.index("BETA"))
+
+    @patch("app.views.participants.supabase_service")
+    @patch("app.views.participants.get_available_years")
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    def test_country_view_json(
+        self,
+        mock_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+        mock_participants_get_available_years,
+        mock_supabase,
+    ):
+        """Accept: application/json の場合にテンプレートと同じデータがJSONで返されることをテスト"""
+        mock_get_available_years.return_value = [2025]
+        mock_participants_get_available_years.return_value = [2025]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+        mock_supabase.get_data.side_effect = participants_get_data_side_effect
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "ja"
+
+        resp = self.client.get(
+            f"/ja/{self.year}/country/fr", headers={"Accept": "application/json"}
+        )
+        self.assertEqual(resp.status_code, 200)
+        self.assertEqual(resp.content_type, "application/json")
+        self.assertIn("Accept", resp.headers["Vary"])
+
+        data = resp.get_json()
+        self.assertEqual(data["country_name"], "フランス")
+        self.assertEqual(data["iso_alpha2"], "fr")
+        self.assertEqual(
+            [participant["name"] for participant in data["participants"]],
+            ["ALPHA", "MULTINATIONAL TEAM", "BETA"],
+        )
+
+        # ブラウザのAcceptヘッダーではHTMLを返す
+        resp = self.client.get(
+            f"/ja/{self.year}/country/fr",
+            headers={"Accept": "text/html,application/xhtml+xml,*/*;q=0.8"},
+        )
+        self.assertEqual(resp.status_code, 200)
+        self.assertIn("text/html", resp.content_type)
+
+    @patch("app.views.participants.supabase_service")
+    @patch("app.views.participants.get_available_years")
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    def test_country_view_unknown_country(
+        self,
+        mock_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+        mock_participants_get_available_years,
+        mock_supabase,
+    ):
+        """存在しない国コード・不正な国コードの場合に404が返されることをテスト"""
+        mock_get_available_years.return_value = [2025]
+        mock_participants_get_available_years.return_value = [2025]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+        mock_supabase.get_data.side_effect = participants_get_data_side_effect
+
+        for iso_alpha2 in ["zz", "fra"]:
+            with self.subTest(iso_alpha2=iso_alpha2):
+                with self.client.session_transaction() as sess:
+                    sess["language"] = "ja"
+
+                resp = self.client.get(f"/ja/{self.year}/country/{iso_alpha2}")
+                self.assertEqual(resp.status_code, 404)
+
+    @patch("app.views.participants.supabase_service")
+    @patch("app.views.participants.get_available_years")
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    def test_country_view_unavailable_year(
+        self,
+        mock_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+        mock_participants_get_available_years,
+        mock_supabase,
+    ):
+        """存在しない年度の場合に404が返されることをテスト"""
+        mock_get_available_years.return_value = [2025]
+        mock_participants_get_available_years.return_value = [2025]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+        mock_supabase.get_data.side_effect = participants_get_data_side_effect
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "ja"
+
+        resp = self.client.get("/ja/2000/country/fr")
+        self.assertEqual(resp.status_code, 404)
+        mock_supabase.get_data.assert_not_called()
+
+    @patch("app.views.participants.supabase_service")
+    @patch("app.views.participants.get_available_years")
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    def test_country_view_supabase_no_response(
+        self,
+        mock_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+        mock_participants_get_available_years,
+        mock_supabase,
+    ):
+        """Supabaseからの応答がない場合に500エラーが返されることをテスト"""
+        mock_get_available_years.return_value = [2025]
+        mock_participants_get_available_years.return_value = [2025]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+
+        # 取得失敗: raise_error=True の呼び出しを例外で表現
+        mock_supabase.get_data.side_effect = Exception("supabase error")
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "ja"
+
+        resp = self.client.get(f"/ja/{self.year}/country/fr")
+        self.assertEqual(resp.status_code, 500)
+
+
+if __name__ == "__main__":
+    unittest.main()
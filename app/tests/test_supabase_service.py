@@ -6,7 +6,7 @@ python -m pytest app/tests/test_supabase_service.py -v
 
 import os
 import unittest
-from unittest.mock import Mock, patch
+from unittest.mock import ANY, Mock, patch
 
 # Supabaseサービスをモックしてからapp.mainをインポート
 with patch("app.context_processors.supabase_service") as mock_supabase:
@@ -298,6 +298,261 @@ class SupabaseServiceTestCase(unittest.TestCase):
                 )
                 self.assertEqual(query.execute_call_count, 1)
 
+    def test_get_data_serves_stale_data_and_refreshes(self):
+        """get_data: 有効期限切れのデータを返しつつ、バックグラウンドで更新することを検証する。"""
+        from time import time
+
+        from app.models.supabase_client import SupabaseService
+
+        dict_cache = self.DictCache()
+        with patch("app.main.flask_cache", dict_cache):
+            query = self.QueryMock()
+            query.response_data = [{"id": 1, "name": "Alice"}]
+
+            with patch(
+                "app.models.supabase_client.create_client"
+            ) as mock_create_client:
+                mock_create_client.return_value = self.FakeClient(query)
+
+                service = SupabaseService()
+                service.get_data(table="User")
+
+                # データと有効期限は1つのキーにまとめて保存される
+                cache_key = service._generate_cache_key(table="User")
+                self.assertEqual(list(dict_cache.store), [cache_key])
+                self.assertGreater(dict_cache.store[cache_key]["fresh_until"], time())
+
+                # 有効期限切れを再現
+                dict_cache.store[cache_key]["fresh_until"] = 0
+                query.response_data = [{"id": 1, "name": "Bob"}]
+
+                with patch("app.models.supabase_client.Thread") as mock_thread:
+                    result = service.get_data(table="User")
+                    self.assertEqual(result, [{"id": 1, "name": "Alice"}])
+
+                    # 更新中に再度アクセスしてもスレッドは1つだけ
+                    service.get_data(table="User")
+                    self.assertEqual(mock_thread.call_count, 1)
+
+                    # スレッドの処理を同期的に実行
+                    mock_thread.call_args.kwargs["target"]()
+
+                self.assertEqual(
+                    dict_cache.store[cache_key]["data"], [{"id": 1, "name": "Bob"}]
+                )
+                self.assertGreater(dict_cache.store[cache_key]["fresh_until"], time())
+                self.assertEqual(service._refreshing_keys, set())
+
+    def test_get_data_deduplicates_concurrent_queries(self):
+        """get_data: 同じクエリの同時実行がSupabaseへの1回の問い合わせにまとめられることを検証する。"""
+        import threading
+        import time
+
+        from app.models.supabase_client import SupabaseService
+
+        dict_cache = self.DictCache()
+        with patch("app.main.flask_cache", dict_cache):
+            query = self.QueryMock()
+            query.response_data = [{"id": 1, "name": "Alice"}]
+
+            # 問い合わせに時間がかかる状況を再現
+            original_execute = query.execute
+
+            def slow_execute():
+                time.sleep(0.1)
+                return original_execute()
+
+            query.execute = slow_execute
+
+            with patch(
+                "app.models.supabase_client.create_client"
+            ) as mock_create_client:
+                mock_create_client.return_value = self.FakeClient(query)
+
+                service = SupabaseService()
+                results = []
+                threads = [
+                    threading.Thread(
+                        target=lambda: results.append(service.get_data(table="User"))
+                    )
+                    for _ in range(5)
+                ]
+                for thread in threads:
+                    thread.start()
+                for thread in threads:
+                    thread.join()
+
+                self.assertEqual(query.execute_call_count, 1)
+                self.assertEqual(results, [[{"id": 1, "name": "Alice"}]] * 5)
+
+                # 使い終わったロックは残さない
+                self.assertEqual(service._key_locks, {})
+
+    def test_get_data_shares_failure_with_waiting_queries(self):
+        """get_data: 同時実行中の問い合わせが失敗した場合、待っていたリクエストは問い合わせないことを検証する。"""
+        import threading
+        import time
+
+        import httpx
+
+        from app.models.supabase_client import SupabaseService
+
+        dict_cache = self.DictCache()
+        with patch("app.main.flask_cache", dict_cache):
+            query = self.QueryMock()
+
+            # 応答しないSupabaseへの問い合わせがタイムアウトする状況を再現
+            def hung_execute():
+                query.execute_call_count += 1
+                time.sleep(0.1)
+                raise httpx.ReadTimeout("timeout")
+
+            query.execute = hung_execute
+
+            with patch(
+                "app.models.supabase_client.create_client"
+            ) as mock_create_client:
+                mock_create_client.return_value = self.FakeClient(query)
+
+                service = SupabaseService()
+                results = []
+                threads = [
+                    threading.Thread(
+                        target=lambda: results.append(service.get_data(table="User"))
+                    )
+                    for _ in range(5)
+                ]
+                started_at = time.monotonic()
+                for thread in threads:
+                    thread.start()
+                for thread in threads:
+                    thread.join()
+
+                self.assertEqual(query.execute_call_count, 1)
+                self.assertEqual(results, [[]] * 5)
+                self.assertLess(time.monotonic() - started_at, 0.3)
+                self.assertEqual(service._consecutive_failures, 1)
+                self.assertEqual(service._key_locks, {})
+
+    def test_get_data_circuit_breaker(self):
+        """get_data: 連続失敗でSupabaseへの問い合わせを止め、一定時間後に再開することを検証する。"""
+        import httpx
+        from postgrest.exceptions import APIError
+
+        from app.models.supabase_client import (
+            CIRCUIT_BREAKER_COOLDOWN,
+            CIRCUIT_BREAKER_THRESHOLD,
+            SupabaseService,
+            SupabaseUnavailableError,
+        )
+
+        dict_cache = self.DictCache()
+        with patch("app.main.flask_cache", dict_cache):
+            query = self.QueryMock()
+            query.response_data = [{"id": 1, "name": "Alice"}]
+            original_execute = query.execute
+            query.error = APIError({"code": "42703", "message": "bad column"})
+
+            def flaky_execute():
+                if query.error:
+                    query.execute_call_count += 1
+                    raise query.error
+                return original_execute()
+
+            query.execute = flaky_execute
+
+            with patch(
+                "app.models.supabase_client.create_client"
+            ) as mock_create_client, patch(
+                "app.models.supabase_client.monotonic"
+            ) as mock_monotonic:
+                mock_create_client.return_value = self.FakeClient(query)
+                mock_monotonic.return_value = 1000.0
+
+                service = SupabaseService()
+
+                # クエリの誤りはSupabaseの障害ではないため数えない
+                for _ in range(CIRCUIT_BREAKER_THRESHOLD):
+                    self.assertEqual(service.get_data(table="User"), [])
+                self.assertIsNone(service._circuit_opened_at)
+                self.assertEqual(service._consecutive_failures, 0)
+
+                query.execute_call_count = 0
+                query.error = httpx.ReadTimeout("timeout")
+                for _ in range(CIRCUIT_BREAKER_THRESHOLD):
+                    self.assertEqual(service.get_data(table="User"), [])
+                self.assertEqual(query.execute_call_count, CIRCUIT_BREAKER_THRESHOLD)
+
+                # 開いている間は問い合わせずに失敗する
+                self.assertTrue(service.is_circuit_open())
+                with self.assertRaises(SupabaseUnavailableError):
+                    service.get_data(table="User", raise_error=True)
+                self.assertEqual(query.execute_call_count, CIRCUIT_BREAKER_THRESHOLD)
+
+                # 一定時間経過後は再度問い合わせ、成功すれば閉じる
+                mock_monotonic.return_value = 1000.0 + CIRCUIT_BREAKER_COOLDOWN
+                query.error = None
+                self.assertEqual(
+                    service.get_data(table="User"), [{"id": 1, "name": "Alice"}]
+                )
+                self.assertIsNone(service._circuit_opened_at)
+                self.assertEqual(service._consecutive_failures, 0)
+                self.assertFalse(service.is_circuit_open())
+
+    def test_get_data_retries_transient_errors(self):
+        """get_data: 502/503/504は再試行し、それ以外のエラーは再試行しないことを検証する。"""
+        from postgrest.exceptions import APIError
+
+        from app.models.supabase_client import SupabaseService
+
+        dict_cache = self.DictCache()
+        with patch("app.main.flask_cache", dict_cache):
+            query = self.QueryMock()
+            query.response_data = [{"id": 1, "name": "Alice"}]
+            original_execute = query.execute
+            # ゲートウェイがHTMLを返した場合にpostgrestが送出する形（codeはHTTPステータスのint）
+            query.errors = [
+                APIError(
+                    {
+                        "message": "JSON could not be generated",
+                        "code": 503,
+                        "hint": "Refer to full message for details",
+                        "details": "b'<html>503 Service Unavailable</html>'",
+                    }
+                )
+            ]
+
+            def flaky_execute():
+                if query.errors:
+                    query.execute_call_count += 1
+                    raise query.errors.pop(0)
+                return original_execute()
+
+            query.execute = flaky_execute
+
+            with patch(
+                "app.models.supabase_client.create_client"
+            ) as mock_create_client, patch(
+                "app.models.supabase_client.sleep"
+            ) as mock_sleep:
+                mock_create_client.return_value = self.FakeClient(query)
+
+                service = SupabaseService()
+
+                # 一時的なエラーは再試行して成功する
+                self.assertEqual(
+                    service.get_data(table="User"), [{"id": 1, "name": "Alice"}]
+                )
+                self.assertEqual(query.execute_call_count, 2)
+                self.assertEqual(mock_sleep.call_count, 1)
+
+                # クエリの誤りは再試行しない
+                query.execute_call_count = 0
+                query.errors = [APIError({"code": "42703", "message": "bad column"})]
+                self.assertEqual(service.get_data(table="Participant"), [])
+                self.assertEqual(query.execute_call_count, 1)
+                self.assertEqual(mock_sleep.call_count, 1)
+
     def test_get_data_returns_dataframe_when_pandas_true(self):
         """get_data: pandas=True で DataFrame を返すことを検証する。"""
         import pandas as pd
@@ -404,7 +659,10 @@ class SupabaseServiceTestCase(unittest.TestCase):
 
     def test_read_only_client_property_getter(self):
         """read_only_client propertyのgetter動作を検証する。"""
-        from app.models.supabase_client import SupabaseService
+        from app.models.supabase_client import (
+            SUPABASE_QUERY_TIMEOUT,
+            SupabaseService,
+        )
 
         service = SupabaseService()
 
@@ -416,8 +674,11 @@ class SupabaseServiceTestCase(unittest.TestCase):
             client1 = service.read_only_client
             self.assertEqual(client1, mock_client)
             mock_create_client.assert_called_once_with(
-                os.getenv("SUPABASE_URL"), os.getenv("SUPABASE_ANON_KEY")
+                os.getenv("SUPABASE_URL"), os.getenv("SUPABASE_ANON_KEY"), options=ANY
             )
+            # 問い合わせのタイムアウトが設定されている
+            options = mock_create_client.call_args.kwargs["options"]
+            self.assertEqual(options.postgrest_client_timeout, SUPABASE_QUERY_TIMEOUT)
 
         # 2回目のアクセス - キャッシュされたクライアントが返される
         client2 = service.read_only_client
@@ -425,7 +686,10 @@ class SupabaseServiceTestCase(unittest.TestCase):
 
     def test_admin_client_property_getter(self):
         """admin_client propertyのgetter動作を検証する。"""
-        from app.models.supabase_client import SupabaseService
+        from app.models.supabase_client import (
+            SUPABASE_QUERY_TIMEOUT,
+            SupabaseService,
+        )
 
         service = SupabaseService()
 
@@ -437,8 +701,11 @@ class SupabaseServiceTestCase(unittest.TestCase):
             client1 = service.admin_client
             self.assertEqual(client1, mock_client)
             mock_create_client.assert_called_once_with(
-                os.getenv("SUPABASE_URL"), os.getenv("SUPABASE_SERVICE_ROLE_KEY")
+                os.getenv("SUPABASE_URL"), os.getenv("SUPABASE_SERVICE_ROLE_KEY"), options=ANY
             )
+            # 問い合わせのタイムアウトが設定されている
+            options = mock_create_client.call_args.kwargs["options"]
+            self.assertEqual(options.postgrest_client_timeout, SUPABASE_QUERY_TIMEOUT)
 
         # 2回目のアクセス - キャッシュされたクライアントが返される
         client2 = service.admin_client
@@ -0,0 +1,122 @@
+"""
+未翻訳msgidレポートのテストモジュール
+
+python -m pytest app/tests/test_translation_report.py -v
+"""
+
+import tempfile
+import unittest
+from pathlib import Path
+from unittest.mock import patch
+
+import polib
+
+from app.util.translation_report import get_missing_translations
+
+
+def save_catalog(path: Path, entries: list[dict]):
+    """テスト用の.po/.potファイルを保存する"""
+    path.parent.mkdir(parents=True, exist_ok=True)
+    catalog = polib.POFile()
+    for entry in entries:
+        catalog.append(polib.POEntry(**entry))
+    catalog.save(str(path))
+
+
+class MissingTranslationsTestCase(unittest.TestCase):
+    """get_missing_translationsのテストケース"""
+
+    def setUp(self):
+        """テスト用の翻訳ディレクトリを作成する"""
+        self.temp_dir = tempfile.TemporaryDirectory()
+        self.base = Path(self.temp_dir.name)
+        self.pot_file_path = self.base / "messages.pot"
+        self.translations_dir = self.base / "translations"
+
+        save_catalog(
+            self.pot_file_path,
+            [
+                {"msgid": "出場者", "msgstr": ""},
+                {"msgid": "ルール", "msgstr": ""},
+                {"msgid": "チケット", "msgstr": ""},
+                {"msgid": "審査員", "msgstr": ""},
+            ],
+        )
+
+    def tearDown(self):
+        """テスト用の翻訳ディレクトリを削除する"""
+        self.temp_dir.cleanup()
+
+    @patch("app.util.translation_report.SUPPORTED_LOCALES", ["ja", "en", "ko"])
+    def test_report_per_language(self):
+        """missing / untranslated / fuzzy が言語ごとに分類されることを確認"""
+        save_catalog(
+            self.translations_dir / "en" / "LC_MESSAGES" / "messages.po",
+            [
+                {"msgid": "出場者", "msgstr": "Participants"},
+                {"msgid": "ルール", "msgstr": ""},
+                {"msgid": "チケット", "msgstr": "Ticket", "flags": ["fuzzy"]},
+            ],
+        )
+
+        report = get_missing_translations(self.pot_file_path, self.translations_dir)
+
+        # 原文の言語はレポートに含まれない
+        self.assertNotIn("ja", report)
+
+        self.assertEqual(report["en"]["missing"], ["審査員"])
+        self.assertEqual(report["en"]["untranslated"], ["ルール"])
+        self.assertEqual(report["en"]["fuzzy"], ["チケット"])
+        self.assertEqual(report["en"]["coverage"], 0.25)
+
+        # .poが存在しない言語は全msgidがmissing
+        self.assertEqual(len(report["ko"]["missing"]), 4)
+        self.assertEqual(report["ko"]["coverage"], 0.0)
+
+    @patch("app.util.translation_report.SUPPORTED_LOCALES", ["ja", "en"])
+    def test_obsolete_entries_are_ignored(self):
+        """.potに存在しない古いmsgidはレポート対象外であることを確認"""
+        save_catalog(
+            self.translations_dir / "en" / "LC_MESSAGES" / "messages.po",
+            [
+                {"msgid": "出場者", "msgstr": "Participants"},
+                {"msgid": "ルール", "msgstr": "Rule"},
+                {"msgid": "チケット", "msgstr": "Ticket"},
+                {"msgid": "審査員", "msgstr": "Judges"},
+                {"msgid": "削除済み", "msgstr": ""},
+            ],
+        )
+
+        report = get_missing_translations(self.pot_file_path, self.translations_dir)
+
+        self.assertEqual(report["en"]["missing"], [])
+        self.assertEqual(report["en"]["untranslated"], [])
+        self.assertEqual(report["en"]["coverage"], 1.0)
+
+    @patch("app.util.translation_report.SUPPORTED_LOCALES", ["ja", "en"])
+    def test_entries_with_context(self):
+        """msgctxtが異なる同じmsgidが別々のキーとして扱われることを確認"""
+        save_catalog(
+            self.pot_file_path,
+            [
+                {"msgid": "トップ", "msgstr": "", "msgctxt": "navigation"},
+                {"msgid": "トップ", "msgstr": "", "msgctxt": "ranking"},
+            ],
+        )
+        save_catalog(
+            self.translations_dir / "en" / "LC_MESSAGES" / "messages.po",
+            [
+                {"msgid": "トップ", "msgstr": "Top", "msgctxt": "navigation"},
+                {"msgid": "トップ", "msgstr": "", "msgctxt": "ranking"},
+            ],
+        )
+
+        report = get_missing_translations(self.pot_file_path, self.translations_dir)
+
+        self.assertEqual(report["en"]["missing"], [])
+        self.assertEqual(report["en"]["untranslated"], ["ranking\x04トップ"])
+        self.assertEqual(report["en"]["coverage"], 0.5)
+
+
+if __name__ == "__main__":
+    unittest.main()
@@ -235,6 +235,23 @@ class AppUrlsTestCase(unittest.TestCase):
                         {"id": 2, "name": "Solo", "is_team": False},
                     ]
                 )
+            # 国別ページ（/japan, /korea）の国データ
+            if table == "Country":
+                if filters.get("iso_alpha2") == "kr":
+                    return [
+                        {
+                            "iso_code": 410,
+                            "names": create_country_names_mock("Korea"),
+                            "iso_alpha2": "kr",
+                        }
+                    ]
+                return [
+                    {
+                        "iso_code": 392,
+                        "names": create_country_names_mock(),
+                        "iso_alpha2": "jp",
+                    }
+                ]
             if table == "Participant":
                 # 日本の出場者データ（iso_code=392）
                 if filters.get("iso_code") == 392:
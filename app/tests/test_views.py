@@ -5,6 +5,7 @@ python -m pytest app/tests/test_views.py -v
 """
 
 import json
+import os
 import unittest
 from unittest.mock import patch
 
@@ -170,6 +171,61 @@ class ViewsTestCase(unittest.TestCase):
             "JAPANESE MEMBER, KOREAN MEMBER, AMERICAN MEMBER",
         )  # 3名のメンバー
 
+    def test_normalize_search_keyword(self):
+        """出場者検索キーワードの正規化をテスト"""
+        from app.views.beatboxer_finder import normalize_search_keyword
+
+        self.assertEqual(normalize_search_keyword("ｗｉｎｇ"), "WING")  # 全角→半角
+        self.assertEqual(normalize_search_keyword("ろふ"), "ロフ")  # ひらがな→カタカナ
+        self.assertEqual(normalize_search_keyword(" 윙 "), "윙")
+        self.assertEqual(normalize_search_keyword("Rofu"), "ROFU")
+
+    def test_beatboxer_name_aliases_file(self):
+        """別名ファイルが読み込まれ、別名が正規化されていることをテスト"""
+        from app.views.beatboxer_finder import BEATBOXER_NAME_ALIASES
+
+        self.assertIn("ロフ", BEATBOXER_NAME_ALIASES["ROFU"])
+        self.assertIn("윙", BEATBOXER_NAME_ALIASES["WING"])
+        for name, aliases in BEATBOXER_NAME_ALIASES.items():
+            self.assertEqual(name, name.upper())
+            self.assertTrue(aliases)
+
+    @patch.dict(
+        "app.views.beatboxer_finder.BEATBOXER_NAME_ALIASES",
+        {"HIKAKUN": ["ヒカクン"]},
+    )
+    @patch("app.views.beatboxer_finder.supabase_service")
+    def test_search_participants_by_alias(self, mock_supabase):
+        """ひらがな・カタカナの別名で、ローマ字で登録された出場者が見つかることをテスト"""
+        participants_data = [
+            {
+                "id": 1,
+                "name": "Hikakun",
+                "category": 1,
+                "ticket_class": "GBB Seed",
+                "is_cancelled": False,
+                "Category": {"name": "Solo", "is_team": False},
+                "ParticipantMember": [],
+            },
+            {
+                "id": 2,
+                "name": "Other",
+                "category": 1,
+                "ticket_class": "GBB Seed",
+                "is_cancelled": False,
+                "Category": {"name": "Solo", "is_team": False},
+                "ParticipantMember": [],
+            },
+        ]
+
+        for keyword in ["ひかくん", "ヒカクン", "Hikakun"]:
+            mock_supabase.get_data.side_effect = [participants_data, []]
+            response = self.client.post(
+                f"/{self.year}/search_participants", json={"keyword": keyword}
+            )
+            self.assertEqual(response.status_code, 200)
+            self.assertEqual(response.get_json()[0]["name"], "HIKAKUN")
+
     @patch("app.context_processors.supabase_service")
     @patch("app.views.participants.supabase_service")
     @patch("app.views.participants.get_available_years")
@@ -289,3 +345,315 @@ class ViewsTestCase(unittest.TestCase):
 
         # COMEBACK Wildcardが正しく処理されていることを確認
         # （実際のソート順序はビュー内で処理されるため、レスポンスに含まれることを確認）
+
+    def test_post_oversized_body_rejected(self):
+        """MAX_CONTENT_LENGTHを超えるリクエストボディが413で拒否されることを確認"""
+        oversized_input = "A" * (app.config["MAX_CONTENT_LENGTH"] + 1)
+
+        response = self.client.post(
+            "/search_suggestions", json={"input": oversized_input}
+        )
+
+        self.assertEqual(response.status_code, 413)
+
+    def test_canonical_host_redirect(self):
+        """正規ドメイン以外・httpでのアクセスがhttpsの正規ドメインへリダイレクトされることを確認"""
+        with patch.dict(app.config, {"CANONICAL_HOST": "gbbinfo-jpn.onrender.com"}):
+            response = self.client.get(
+                f"/ja/{self.year}/participants?category=Solo",
+                base_url="http://old-domain.example.com",
+            )
+            self.assertEqual(response.status_code, 301)
+            self.assertEqual(
+                response.headers["Location"],
+                f"https://gbbinfo-jpn.onrender.com/ja/{self.year}/participants?category=Solo",
+            )
+
+            # POSTはメソッドを維持する
+            response = self.client.post(
+                f"/{self.year}/search_participants",
+                json={"keyword": "test"},
+                base_url="https://old-domain.example.com",
+            )
+            self.assertEqual(response.status_code, 308)
+
+            # ヘルスチェックは対象外
+            response = self.client.get(
+                "/health", base_url="http://old-domain.example.com"
+            )
+            self.assertEqual(response.status_code, 200)
+
+            # プロキシを信頼しない（既定の）場合、正規ドメインへのhttpは
+            # リダイレクトしない（プロキシ背後での無限リダイレクトを防ぐ）
+            response = self.client.get(
+                "/robots.txt", base_url="http://gbbinfo-jpn.onrender.com"
+            )
+            self.assertEqual(response.status_code, 200)
+
+        with patch.dict(
+            app.config,
+            {"CANONICAL_HOST": "gbbinfo-jpn.onrender.com", "TRUSTED_PROXY_COUNT": 1},
+        ):
+            response = self.client.get(
+                "/robots.txt", base_url="http://gbbinfo-jpn.onrender.com"
+            )
+            self.assertEqual(response.status_code, 301)
+            self.assertEqual(
+                response.headers["Location"],
+                "https://gbbinfo-jpn.onrender.com/robots.txt",
+            )
+
+    @patch("app.main.supabase_service")
+    @patch("app.views.participants.supabase_service")
+    @patch("app.views.participants.get_available_years")
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    def test_data_unavailable_notice(
+        self,
+        mock_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+        mock_participants_get_available_years,
+        mock_participants_supabase,
+        mock_main_supabase,
+    ):
+        """Supabase停止中にデータを取得できない場合、翻訳済みの503が返されることを確認"""
+        import pandas as pd
+
+        mock_get_available_years.return_value = [self.year]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+        mock_participants_get_available_years.return_value = [self.year]
+        mock_participants_supabase.get_data.return_value = pd.DataFrame()
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "en"
+
+        # サーキットブレーカーが開いている場合は、データを取得できない旨の503
+        mock_main_supabase.is_circuit_open.return_value = True
+        response = self.client.get(f"/en/{self.year}/participants")
+        self.assertEqual(response.status_code, 503)
+        self.assertIn("Retry-After", response.headers)
+        self.assertIn(
+            "Data is temporarily unavailable.", response.get_data(as_text=True)
+        )
+
+        # それ以外の取得失敗は従来どおり500
+        mock_main_supabase.is_circuit_open.return_value = False
+        response = self.client.get(f"/en/{self.year}/participants")
+        self.assertEqual(response.status_code, 500)
+
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    def test_maintenance_mode(
+        self,
+        mock_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+    ):
+        """メンテナンス中は503とRetry-Afterが返され、/healthは除外されることを確認"""
+        mock_get_available_years.return_value = [self.year]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+
+        with patch.dict(app.config, {"MAINTENANCE_MODE": True}):
+            with self.client.session_transaction() as sess:
+                sess["language"] = "ja"
+
+            response = self.client.get(f"/ja/{self.year}/top")
+            self.assertEqual(response.status_code, 503)
+            self.assertEqual(
+                response.headers["Retry-After"],
+                str(app.config["MAINTENANCE_RETRY_AFTER"]),
+            )
+            self.assertIn("503", response.get_data(as_text=True))
+
+            # HEADはGETと同じくHTMLの503として扱う
+            response = self.client.head(f"/ja/{self.year}/top")
+            self.assertEqual(response.status_code, 503)
+            self.assertEqual(response.mimetype, "text/html")
+
+            response = self.client.post(
+                f"/{self.year}/search_participants", json={"keyword": "test"}
+            )
+            self.assertEqual(response.status_code, 503)
+            self.assertEqual(response.get_json(), {"error": "maintenance"})
+
+            response = self.client.get("/health")
+            self.assertEqual(response.status_code, 200)
+
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    @patch("app.views.common.get_available_years")
+    def test_unknown_content_redirects_to_similar_content(
+        self,
+        mock_common_get_available_years,
+        mock_context_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+    ):
+        """存在しないコンテンツで類似度が十分高い候補が1件の場合、その候補へリダイレクトされることを確認"""
+        mock_common_get_available_years.return_value = [self.year]
+        mock_context_get_available_years.return_value = [self.year]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "ja"
+
+        response = self.client.get(f"/ja/{self.year}/rules?scroll=judges")
+
+        self.assertEqual(response.status_code, 302)
+        self.assertEqual(
+            response.headers["Location"], f"/ja/{self.year}/rule?scroll=judges"
+        )
+
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    @patch("app.views.common.get_available_years")
+    def test_unknown_content_shows_suggestions(
+        self,
+        mock_common_get_available_years,
+        mock_context_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+    ):
+        """存在しないコンテンツで候補が曖昧な場合、404ページに候補リンクが表示されることを確認"""
+        mock_common_get_available_years.return_value = [self.year]
+        mock_context_get_available_years.return_value = [self.year]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "ja"
+
+        # "tckt" と "ticket" の類似度は80（リダイレクトの閾値未満）
+        response = self.client.get(f"/ja/{self.year}/tckt")
+
+        self.assertEqual(response.status_code, 404)
+        response_data = response.get_data(as_text=True)
+        self.assertIn(f'href="/ja/{self.year}/ticket"', response_data)
+        self.assertNotIn("location.replace", response_data)
+
+    @patch("app.context_processors.get_translated_urls")
+    @patch("app.context_processors.is_gbb_ended")
+    @patch("app.context_processors.get_available_years")
+    @patch("app.views.common.get_available_years")
+    def test_unknown_content_without_suggestions(
+        self,
+        mock_common_get_available_years,
+        mock_context_get_available_years,
+        mock_is_gbb_ended,
+        mock_get_translated_urls,
+    ):
+        """似ているコンテンツがない場合、通常の404ページが表示され、ログに出ることを確認"""
+        from contextlib import redirect_stdout
+        from io import StringIO
+
+        mock_common_get_available_years.return_value = [self.year]
+        mock_context_get_available_years.return_value = [self.year]
+        mock_is_gbb_ended.return_value = False
+        mock_get_translated_urls.return_value = set()
+
+        with self.client.session_transaction() as sess:
+            sess["language"] = "ja"
+
+        output = StringIO()
+        with redirect_stdout(output):
+            response = self.client.get(
+                f"/ja/{self.year}/zzzzzzzz",
+                headers={"Referer": "https://example.com/links"},
+            )
+
+        self.assertEqual(response.status_code, 404)
+        self.assertIn(
+            f"404 Not Found: /ja/{self.year}/zzzzzzzz | https://example.com/links",
+            output.getvalue(),
+        )
+        self.assertIn("location.replace", response.get_data(as_text=True))
+        # エラーページは各言語版が存在しないため、hreflangを出さない
+        self.assertNotIn("hreflang", response.get_data(as_text=True))
+
+    def test_pgettext_is_available_in_templates(self):
+        """テンプレートでpgettextが使え、未翻訳の場合は原文が返ることを確認"""
+        with app.test_request_context("/ja/2025/top"):
+            template = app.jinja_env.from_string('{{ pgettext("ranking", "トップ") }}')
+            self.assertEqual(template.render(), "トップ")
+
+    @patch("app.main.get_missing_translations")
+    def test_check_translations_command(self, mock_get_missing_translations):
+        """check-translationsコマンドが翻訳率を表示し、--strictで失敗することを確認"""
+        mock_get_missing_translations.return_value = {
+            "en": {
+                "missing": [],
+                "untranslated": ["ルール"],
+                "fuzzy": [],
+                "coverage": 0.5,
+            },
+        }
+        runner = app.test_cli_runner()
+
+        result = runner.invoke(args=["check-translations"])
+        self.assertEqual(result.exit_code, 0)
+        self.assertIn("en: 50.0% (missing=0, untranslated=1, fuzzy=0)", result.output)
+
+        result = runner.invoke(args=["check-translations", "--strict"])
+        self.assertEqual(result.exit_code, 1)
+
+    def test_validate_config_command(self):
+        """validate-configコマンドが設定の不足を検出することを確認"""
+        runner = app.test_cli_runner()
+        valid_config = {
+            "SECRET_KEY": "test",
+            "CACHE_TYPE": "null",
+            "ACCESS_LOG_FORMAT": None,
+            "CANONICAL_HOST": None,
+        }
+        valid_env = {
+            "SUPABASE_URL": "http://localhost",
+            "SUPABASE_ANON_KEY": "anon",
+            "SUPABASE_SERVICE_ROLE_KEY": "service",
+        }
+
+        with patch.dict(app.config, valid_config), patch.dict(os.environ, valid_env):
+            result = runner.invoke(args=["validate-config"])
+            self.assertEqual(result.exit_code, 0)
+            self.assertIn("ok", result.output)
+
+            with patch.dict(app.config, {"ACCESS_LOG_FORMAT": "xml"}):
+                result = runner.invoke(args=["validate-config"])
+            self.assertEqual(result.exit_code, 1)
+            self.assertIn("ACCESS_LOG_FORMAT", result.output)
+
+    @patch("app.main.supabase_service")
+    def test_export_participants_command(self, mock_supabase):
+        """export-participantsコマンドが出場者一覧をCSVで出力することを確認"""
+        mock_supabase.get_data.return_value = [
+            {
+                "id": 1,
+                "name": "WING",
+                "ticket_class": "GBB Seed",
+                "is_cancelled": False,
+                "Category": {"name": "Solo"},
+                "Country": {"iso_alpha2": "kr"},
+            }
+        ]
+        runner = app.test_cli_runner()
+
+        result = runner.invoke(args=["export-participants", "--year", "2025"])
+
+        self.assertEqual(result.exit_code, 0)
+        self.assertEqual(
+            result.output.splitlines(),
+            [
+                "id,name,category,ticket_class,is_cancelled,iso_alpha2",
+                "1,WING,Solo,GBB Seed,False,kr",
+            ],
+        )
+        self.assertEqual(
+            mock_supabase.get_data.call_args.kwargs["filters"], {"year": 2025}
+        )
@@ -0,0 +1,63 @@
+from collections import Counter
+from html.parser import HTMLParser
+
+HEADING_TAGS = ("h1", "h2", "h3", "h4", "h5", "h6")
+
+
+class A11yAuditParser(HTMLParser):
+    """
+    レンダリング済みHTMLからアクセシビリティ上の問題を検出するパーサー。
+
+    Attributes:
+        warnings (list[str]): 検出した問題のメッセージ。
+    """
+
+    def __init__(self):
+        super().__init__()
+        self.warnings = []
+        self._previous_heading_level = 0
+
+    def handle_starttag(self, tag, attrs):
+        attributes = dict(attrs)
+
+        if tag == "html" and not attributes.get("lang"):
+            self.warnings.append("<html>にlang属性がありません")
+
+        # 装飾目的の画像は alt="" を指定する
+        elif tag == "img" and "alt" not in attributes:
+            src = attributes.get("src", "")
+            self.warnings.append(f"<img>にalt属性がありません: {src}")
+
+        elif tag in HEADING_TAGS:
+            level = int(tag[1])
+            if level > self._previous_heading_level + 1:
+                self.warnings.append(
+                    f"見出しレベルが飛んでいます: h{self._previous_heading_level} → {tag}"
+                )
+            self._previous_heading_level = level
+
+
+def audit_html(html: str):
+    """
+    HTMLのアクセシビリティ上の問題を検出する。
+
+    Args:
+        html (str): レンダリング済みのHTML。
+
+    Returns:
+        list[str]: 検出した問題のメッセージ。問題がない場合は空リスト。
+
+    Note:
+        - <html>のlang属性、<img>のalt属性、見出しレベルの飛び（h1 → h3 など）を検出する。
+        - 同じ問題が複数回ある場合は1件にまとめ、回数を付ける（例: "... (×3)"）。
+        - 開発時の確認用のため、ローカル環境でのみ使用する。
+    """
+    parser = A11yAuditParser()
+    parser.feed(html)
+    parser.close()
+
+    counts = Counter(parser.warnings)
+    return [
+        f"{warning} (×{count})" if count > 1 else warning
+        for warning, count in counts.items()
+    ]
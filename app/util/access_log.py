@@ -0,0 +1,83 @@
+import json
+from datetime import datetime, timezone
+from time import perf_counter
+
+from flask import g, has_request_context, request
+
+ACCESS_LOG_FORMATS = ("json", "combined")
+
+
+def start_request_timer():
+    """リクエストの処理時間と、外部API（Supabase）の待ち時間の計測を開始する。"""
+    g.request_started_at = perf_counter()
+    g.upstream_seconds = 0.0
+    g.upstream_calls = 0
+
+
+def add_upstream_time(seconds: float):
+    """
+    外部API（Supabase）の待ち時間をリクエスト単位で加算する。
+
+    Args:
+        seconds (float): 外部API呼び出しにかかった秒数。
+
+    Note:
+        リクエスト外（バックグラウンドスレッドなど）から呼ばれた場合は何もしない。
+    """
+    if not has_request_context() or "upstream_seconds" not in g:
+        return
+    g.upstream_seconds += seconds
+    g.upstream_calls += 1
+
+
+def format_access_log(response, log_format: str) -> str:
+    """
+    アクセスログを1行の文字列に整形する。
+
+    Args:
+        response (flask.Response): レスポンスオブジェクト。
+        log_format (str): "json" または "combined"（Apache Combined Log Format）。
+
+    Returns:
+        str: 整形済みのアクセスログ。
+
+    Note:
+        combined は既存の集計ツールで読めるよう標準の項目のみ出力する。
+        処理時間と外部APIの待ち時間の内訳は json でのみ出力する。
+    """
+    now = datetime.now(timezone.utc).astimezone()
+    size = response.content_length
+    referer = request.referrer or "-"
+    user_agent = request.user_agent.string or "-"
+
+    if log_format == "combined":
+        protocol = request.environ.get("SERVER_PROTOCOL", "HTTP/1.1")
+        request_line = f"{request.method} {request.full_path.rstrip('?')} {protocol}"
+        return (
+            f"{request.remote_addr or '-'} - - "
+            f"[{now.strftime('%d/%b/%Y:%H:%M:%S %z')}] "
+            f'"{request_line}" {response.status_code} '
+            f"{size if size is not None else '-'} "
+            f'"{referer}" "{user_agent}"'
+        )
+
+    started_at = g.get("request_started_at")
+    duration_ms = (perf_counter() - started_at) * 1000 if started_at else None
+
+    return json.dumps(
+        {
+            "time": now.isoformat(),
+            "remote_addr": request.remote_addr,
+            "method": request.method,
+            "path": request.path,
+            "query": request.query_string.decode("utf-8", "replace"),
+            "status": response.status_code,
+            "bytes": size,
+            "referer": referer,
+            "user_agent": user_agent,
+            "duration_ms": round(duration_ms, 2) if duration_ms is not None else None,
+            "upstream_ms": round(g.get("upstream_seconds", 0.0) * 1000, 2),
+            "upstream_calls": g.get("upstream_calls", 0),
+        },
+        ensure_ascii=False,
+    )
@@ -0,0 +1,158 @@
+from collections import Counter
+from functools import lru_cache
+from pathlib import Path
+
+import polib
+from flask_babel import get_locale, get_translations
+
+from app.config.config import BASE_DIR, SUPPORTED_LOCALES
+
+TRANSLATIONS_DIR = BASE_DIR / "app" / "translations"
+POT_FILE_PATH = BASE_DIR / "app" / "messages.pot"
+
+# 原文の言語なので翻訳ファイルを持たない
+SOURCE_LANGUAGE = "ja"
+
+# 言語ごとの、原文にフォールバックした翻訳呼び出し回数
+_missing_translation_hits = Counter()
+
+
+def _entry_key(entry):
+    """
+    翻訳エントリを一意に識別するキーを返す。
+
+    Args:
+        entry (polib.POEntry): 翻訳エントリ。
+
+    Returns:
+        str: msgctxtがない場合はmsgid、ある場合は "msgctxt\\x04msgid"（gettextの形式）。
+    """
+    if entry.msgctxt:
+        return f"{entry.msgctxt}\x04{entry.msgid}"
+    return entry.msgid
+
+
+def get_missing_translations(
+    pot_file_path: Path = POT_FILE_PATH, translations_dir: Path = TRANSLATIONS_DIR
+):
+    """
+    messages.pot と各言語の messages.po を比較し、翻訳されていないmsgidを言語ごとに返す。
+
+    Args:
+        pot_file_path (Path, optional): 抽出済みのmessages.potのパス。
+        translations_dir (Path, optional): 言語ごとの翻訳ファイルを格納したディレクトリ。
+
+    Returns:
+        dict: 言語コードをキーとする辞書。各値は以下のキーを持つ。
+            - missing (list[str]): .potにあるが.poに存在しないmsgid（msgctxt付きは "msgctxt\\x04msgid"）
+            - untranslated (list[str]): .poに存在するがmsgstrが空のmsgid
+            - fuzzy (list[str]): fuzzyフラグが付いたmsgid
+            - coverage (float): 翻訳済みmsgidの割合（0.0〜1.0）
+
+    Note:
+        未翻訳・fuzzyのmsgidは、実行時に原文（日本語）へフォールバックして表示される。
+    """
+    pot = polib.pofile(str(pot_file_path))
+    pot_msgids = {_entry_key(entry) for entry in pot if entry.msgid}
+
+    report = {}
+    for language in SUPPORTED_LOCALES:
+        if language == SOURCE_LANGUAGE:
+            continue
+
+        po_file_path = translations_dir / language / "LC_MESSAGES" / "messages.po"
+        if not po_file_path.exists():
+            report[language] = {
+                "missing": sorted(pot_msgids),
+                "untranslated": [],
+                "fuzzy": [],
+                "coverage": 0.0,
+            }
+            continue
+
+        po = polib.pofile(str(po_file_path))
+        active_entries = [
+            entry
+            for entry in po
+            if _entry_key(entry) in pot_msgids and not entry.obsolete
+        ]
+        po_msgids = {_entry_key(entry) for entry in active_entries}
+
+        missing = sorted(pot_msgids - po_msgids)
+        fuzzy = sorted(
+            _entry_key(entry) for entry in active_entries if "fuzzy" in entry.flags
+        )
+        untranslated = sorted(
+            _entry_key(entry)
+            for entry in active_entries
+            if not entry.msgstr and "fuzzy" not in entry.flags
+        )
+
+        fallback_count = len(missing) + len(untranslated) + len(fuzzy)
+        report[language] = {
+            "missing": missing,
+            "untranslated": untranslated,
+            "fuzzy": fuzzy,
+            "coverage": round(1 - fallback_count / len(pot_msgids), 4)
+            if pot_msgids
+            else 1.0,
+        }
+
+    return report
+
+
+@lru_cache(maxsize=1)
+def _get_fallback_msgids():
+    """
+    言語ごとに、原文へフォールバックするmsgidの集合を返す。
+
+    Returns:
+        dict[str, set[str]]: 言語コードをキーとするmsgidの集合。
+    """
+    return {
+        language: set(entries["missing"] + entries["untranslated"] + entries["fuzzy"])
+        for language, entries in get_missing_translations().items()
+    }
+
+
+def gettext_with_hit_counter(msgid: str):
+    """
+    テンプレートの gettext を置き換え、未翻訳msgidの呼び出し回数を記録する。
+
+    Args:
+        msgid (str): 翻訳対象の文字列。
+
+    Returns:
+        str: 翻訳後の文字列。
+    """
+    language = str(get_locale())
+    if msgid in _get_fallback_msgids().get(language, ()):
+        _missing_translation_hits[language] += 1
+    return get_translations().ugettext(msgid)
+
+
+def pgettext_with_hit_counter(context: str, msgid: str):
+    """
+    テンプレートの pgettext を置き換え、未翻訳msgidの呼び出し回数を記録する。
+
+    Args:
+        context (str): msgctxt（例: "navigation", "ranking"）。
+        msgid (str): 翻訳対象の文字列。
+
+    Returns:
+        str: 翻訳後の文字列。
+    """
+    language = str(get_locale())
+    if f"{context}\x04{msgid}" in _get_fallback_msgids().get(language, ()):
+        _missing_translation_hits[language] += 1
+    return get_translations().upgettext(context, msgid)
+
+
+def get_missing_translation_hits():
+    """
+    起動後に記録された、言語ごとの未翻訳msgidの呼び出し回数を返す。
+
+    Returns:
+        dict[str, int]: 言語コードをキーとする呼び出し回数。
+    """
+    return dict(_missing_translation_hits)
@@ -1,8 +1,81 @@
+import json
+import unicodedata
+
 from flask import abort, jsonify, request
 from rapidfuzz import process
 
+from app.config.config import BASE_DIR
 from app.models.supabase_client import supabase_service
 
+# ひらがな→カタカナ変換テーブル（ぁ〜ゖ）
+HIRAGANA_TO_KATAKANA = str.maketrans(
+    {chr(code): chr(code + 0x60) for code in range(0x3041, 0x3097)}
+)
+
+
+def normalize_search_keyword(keyword: str):
+    """
+    出場者検索のキーワードを正規化する。
+
+    Args:
+        keyword (str): 入力されたキーワード。
+
+    Returns:
+        str: 正規化されたキーワード。
+
+    Note:
+        NFKCで全角英数字を半角に変換し、ひらがなをカタカナに揃えて大文字に変換する。
+    """
+    keyword = unicodedata.normalize("NFKC", keyword).strip()
+    return keyword.translate(HIRAGANA_TO_KATAKANA).upper()
+
+
+# MARK: 別名
+BEATBOXER_NAME_ALIASES_PATH = (
+    BASE_DIR / "app" / "config" / "beatboxer_name_aliases.json"
+)
+
+
+def load_beatboxer_name_aliases(path=BEATBOXER_NAME_ALIASES_PATH):
+    """
+    出場者の登録名ごとの別名（日本語・韓国語表記など）をJSONファイルから読み込む。
+
+    Args:
+        path (Path): 別名ファイルのパス。{"登録名": ["別名", ...]} の形式。
+
+    Returns:
+        dict[str, list[str]]: 大文字の登録名をキー、正規化済みの別名のリストを値とする辞書。
+    """
+    with open(path, "r", encoding="utf-8") as f:
+        aliases = json.load(f)
+    return {
+        name.upper(): [normalize_search_keyword(alias) for alias in alias_list]
+        for name, alias_list in aliases.items()
+    }
+
+
+BEATBOXER_NAME_ALIASES = load_beatboxer_name_aliases()
+
+
+def build_search_names(names: list[str]):
+    """
+    検索対象の名前リストに別名を加える。
+
+    Args:
+        names (list[str]): 大文字に変換済みの名前のリスト。
+
+    Returns:
+        tuple[list[str], list[int]]: 検索対象の名前（登録名 + 別名）のリストと、
+            それぞれが元のリストの何番目の名前かを表すインデックスのリスト。
+    """
+    search_names = []
+    name_indexes = []
+    for index, name in enumerate(names):
+        for search_name in [name, *BEATBOXER_NAME_ALIASES.get(name, [])]:
+            search_names.append(search_name)
+            name_indexes.append(index)
+    return search_names, name_indexes
+
 
 # MARK: 出場者検索
 def post_search_participants(year: int):
@@ -19,12 +92,16 @@ def post_search_participants(year: int):
         - 参加者名またはメンバー名にキーワードが部分一致（大文字小文字無視）した参加者を検索する。
         - 参加者情報には、id, name, category, ticket_class, is_cancelled, members, mode（single/team）が含まれる。
         - 参加者名・メンバー名は大文字に変換される。
+        - キーワードはnormalize_search_keywordで正規化してから検索する。
+        - 登録名に加えて、beatboxer_name_aliases.jsonに登録された別名でも検索する。
         - 5件を超える場合は、キーワードとの類似度が高い上位5件のみ返す。
     """
     keyword = request.json.get("keyword")
     if not keyword:
         return jsonify([])
 
+    keyword = normalize_search_keyword(keyword)
+
     try:
         participants_data = supabase_service.get_data(
             table="Participant",
@@ -58,24 +135,26 @@ def post_search_participants(year: int):
     except Exception:
         abort(500)
 
-    # 検索用に参加者名とメンバー名リスト（重複排除）をそれぞれ生成
-    search_name_participants = [
-        participant["name"].upper() for participant in participants_data
-    ]
-    search_name_member_names = [member["name"].upper() for member in members_data]
+    # 検索用に参加者名とメンバー名リスト（別名を含む）をそれぞれ生成
+    search_name_participants, participant_indexes = build_search_names(
+        [participant["name"].upper() for participant in participants_data]
+    )
+    search_name_member_names, member_indexes = build_search_names(
+        [member["name"].upper() for member in members_data]
+    )
 
     extract_result_participants = process.extract(
-        keyword.upper(), search_name_participants, limit=5
+        keyword, search_name_participants, limit=5
     )
     extract_result_member_names = process.extract(
-        keyword.upper(), search_name_member_names, limit=5
+        keyword, search_name_member_names, limit=5
     )
 
     result = []
 
     # ratio をフィールドとして持つ dict のリストにする
     for _, ratio, index in extract_result_participants:
-        participant = participants_data[index]
+        participant = participants_data[participant_indexes[index]]
         member_names_list = [
             member["name"].upper() for member in participant["ParticipantMember"]
         ]
@@ -93,7 +172,7 @@ def post_search_participants(year: int):
         )
 
     for _, ratio, index in extract_result_member_names:
-        member = members_data[index]
+        member = members_data[member_indexes[index]]
         member_names_list = [
             member["name"].upper()
             for member in member["Participant"]["ParticipantMember"]
@@ -1,14 +1,33 @@
 import os
 from datetime import datetime, timedelta, timezone
 
-from flask import jsonify, redirect, render_template, session
+from flask import jsonify, redirect, render_template, request, session
 from flask_babel import format_datetime
 from jinja2 import TemplateNotFound
+from rapidfuzz import fuzz, process
 
-from app.context_processors import get_available_years
+from app.context_processors import get_available_years, get_yearly_content
 from app.models.spreadsheet_client import spreadsheet_service
 from app.util.locale import get_validated_language
 
+# テンプレートファイルではなく専用ルートで提供される年度別コンテンツ
+ROUTED_YEARLY_CONTENTS = ["participants", "result", "cancels", "japan", "korea"]
+
+# この類似度以上の候補が1件だけなら、その候補へリダイレクトする
+SUGGESTION_REDIRECT_SCORE = 85
+# この類似度以上の候補を「もしかして」として表示する
+SUGGESTION_MIN_SCORE = 60
+
+# メンテナンス中も通常どおり応答するパス
+MAINTENANCE_EXEMPT_PATHS = (
+    "/health",
+    "/static/",
+    "/favicon.ico",
+    "/robots.txt",
+    "/manifest.json",
+    "/service-worker.js",
+)
+
 
 # MARK: トップ遷移
 def top_redirect_view():
@@ -73,7 +92,45 @@ def content_view(year: int, content: str):
     try:
         return render_template(f"{year}/{content_basename}.html")
     except TemplateNotFound:
-        return not_found_page_view()
+        pass
+
+    # 存在しないコンテンツの場合、似ているコンテンツを探す
+    suggestions = suggest_contents(year, content_basename)
+    confident = [c for c, score in suggestions if score >= SUGGESTION_REDIRECT_SCORE]
+
+    if len(confident) == 1:
+        redirect_url = f"/{language}/{year}/{confident[0]}"
+        if request.query_string:
+            redirect_url += "?" + request.query_string.decode("utf-8")
+        return redirect(redirect_url)
+
+    suggestion_urls = [f"/{language}/{year}/{c}" for c, _ in suggestions]
+    return not_found_page_view(suggestion_urls)
+
+
+# MARK: 類似コンテンツ
+def suggest_contents(year: int, content: str):
+    """
+    存在しないコンテンツ名に似ている、指定年度のコンテンツ名を返す。
+
+    Args:
+        year (int): 年度
+        content (str): ユーザーが指定したコンテンツ名
+
+    Returns:
+        list[tuple[str, float]]: (コンテンツ名, 類似度) のリスト。類似度の降順で最大3件。
+    """
+    _, contents_per_year = get_yearly_content([year])
+    candidates = sorted(set(contents_per_year) | set(ROUTED_YEARLY_CONTENTS))
+
+    results = process.extract(
+        content.lower(),
+        candidates,
+        scorer=fuzz.ratio,
+        limit=3,
+        score_cutoff=SUGGESTION_MIN_SCORE,
+    )
+    return [(candidate, score) for candidate, score, _ in results]
 
 
 # MARK: 2022ビュー
@@ -153,11 +210,29 @@ def notice_view():
 
 
 # MARK: 404
-def not_found_page_view():
+def not_found_page_view(suggestions: list[str] | None = None):
     """
     404ページを表示する。
+
+    Args:
+        suggestions (list[str] | None): 「もしかして」として表示するURLのリスト。
+            指定された場合はトップページへの自動リダイレクトを行わない。
+
+    Note:
+        リンク切れを見つけるため、リファラーがある場合はパス・リファラー・User-Agentを
+        ログに出す。abort(404)を経由しないビュー（存在しないコンテンツなど）も含めるため、ここで出す。
     """
-    return render_template("common/404.html", is_translated=True), 404
+    if request.referrer:
+        print(
+            f"404 Not Found: {request.path} | {request.referrer} | {request.user_agent}",
+            flush=True,
+        )
+    return (
+        render_template(
+            "common/404.html", is_translated=True, suggestions=suggestions or []
+        ),
+        404,
+    )
 
 
 # MARK: 500
@@ -166,3 +241,56 @@ def internal_server_error_view():
     500ページを表示する。
     """
     return render_template("common/500.html", is_translated=True), 500
+
+
+# MARK: 503
+def maintenance_view(retry_after: int):
+    """
+    メンテナンス中の503ページを表示する。
+
+    Args:
+        retry_after (int): 再アクセスまでの目安の秒数。Retry-Afterヘッダーに設定する。
+
+    Returns:
+        tuple | None: 503レスポンス。メンテナンス対象外のパスの場合はNone。
+
+    Note:
+        検索などのPOST（fetch）にはJSONで返す。HEADはGETと同じく扱う。
+    """
+    if request.path.startswith(MAINTENANCE_EXEMPT_PATHS):
+        return None
+
+    headers = {"Retry-After": str(retry_after)}
+    if request.method not in ("GET", "HEAD"):
+        return jsonify({"error": "maintenance"}), 503, headers
+
+    return (
+        render_template("common/503.html", is_translated=True),
+        503,
+        headers,
+    )
+
+
+# MARK: 503（データ取得不可）
+def data_unavailable_view(retry_after: int):
+    """
+    Supabaseからデータを取得できず、キャッシュもない場合の503ページを表示する。
+
+    Args:
+        retry_after (int): 再アクセスまでの目安の秒数。Retry-Afterヘッダーに設定する。
+
+    Returns:
+        tuple: 503レスポンス。
+
+    Note:
+        検索などのPOST（fetch）にはJSONで返す。HEADはGETと同じく扱う。
+    """
+    headers = {"Retry-After": str(retry_after)}
+    if request.method not in ("GET", "HEAD"):
+        return jsonify({"error": "data_unavailable"}), 503, headers
+
+    return (
+        render_template("common/503.html", is_translated=True, data_unavailable=True),
+        503,
+        headers,
+    )
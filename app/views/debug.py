@@ -0,0 +1,30 @@
+from flask import abort, jsonify
+
+from app.util.translation_report import (
+    get_missing_translation_hits,
+    get_missing_translations,
+)
+
+
+# MARK: 未翻訳一覧
+def i18n_missing_view(IS_LOCAL: bool):
+    """
+    言語ごとの未翻訳msgid一覧をJSONで返す開発者向けビュー。
+
+    Args:
+        IS_LOCAL (bool): ローカル環境かどうかのフラグ。Falseの場合は404を返す。
+
+    Returns:
+        flask.Response: 以下のキーを持つJSONレスポンス。
+            - catalog: get_missing_translations() の結果
+            - hits: get_missing_translation_hits() の結果
+    """
+    if not IS_LOCAL:
+        abort(404)
+
+    return jsonify(
+        {
+            "catalog": get_missing_translations(),
+            "hits": get_missing_translation_hits(),
+        }
+    )
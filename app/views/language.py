@@ -1,8 +1,9 @@
 from urllib.parse import urlparse
 
-from flask import redirect, request, session
+from flask import redirect, request
 
 from app.config.config import SUPPORTED_LOCALES
+from app.context_processors import save_to_session
 
 
 # MARK: URL結合
@@ -57,6 +58,6 @@ def change_language():
             next_url = build_path_with_query_and_fragment(parsed)
 
     # 言語をセッションに保存
-    session["language"] = lang_code
+    save_to_session("language", lang_code)
 
     return redirect(next_url)
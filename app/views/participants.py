@@ -1,4 +1,12 @@
-from flask import abort, redirect, render_template, request, session
+from flask import (
+    abort,
+    jsonify,
+    make_response,
+    redirect,
+    render_template,
+    request,
+    session,
+)
 
 from app.config.config import MULTI_COUNTRY_TEAM_ISO_CODE
 from app.context_processors import get_available_years
@@ -11,6 +19,44 @@ VALID_TICKET_CLASSES = ["all", "wildcard", "seed_right"]
 VALID_CANCEL = ["show", "hide", "only_cancelled"]
 
 
+# MARK: JSON
+def wants_json():
+    """
+    AcceptヘッダーでHTMLよりJSONが優先されているかを判定する。
+
+    Returns:
+        bool: JSONを返すべき場合はTrue。
+    """
+    accept_mimetypes = request.accept_mimetypes
+    best = accept_mimetypes.best_match(["text/html", "application/json"])
+    return (
+        best == "application/json"
+        and accept_mimetypes[best] > accept_mimetypes["text/html"]
+    )
+
+
+def render_participants_template(template_name: str, **context):
+    """
+    出場者系ページをレンダリングする。
+
+    Args:
+        template_name (str): テンプレート名。
+        **context: テンプレートに渡すデータ。
+
+    Returns:
+        Response: Accept: application/json の場合はcontextのJSON、それ以外はHTML。
+
+    Note:
+        同じURLでHTMLとJSONを返し分けるため、Vary: Acceptを付与する。
+    """
+    if wants_json():
+        response = jsonify(context)
+    else:
+        response = make_response(render_template(template_name, **context))
+    response.vary.add("Accept")
+    return response
+
+
 # MARK: 出場者
 def participants_view(year: int):
     """
@@ -80,7 +126,7 @@ def participants_view(year: int):
             "ticket_class": ticket_class,
             "cancel": cancel,
         }
-        return render_template("common/participants.html", **context)
+        return render_participants_template("common/participants.html", **context)
 
     all_category_names = category_data["name"].tolist()
 
@@ -182,76 +228,65 @@ def participants_view(year: int):
         "ticket_class": ticket_class,
         "cancel": cancel,
     }
-    return render_template("common/participants.html", **context)
+    return render_participants_template("common/participants.html", **context)
 
 
 # MARK: 国別出場者
-def participants_country_specific_view(year: int):
-    """指定された年とURLから取得した国名に基づいて、該当国の出場者リストを取得し、テンプレートにレンダリングするビュー関数。
+def get_country_participants(year: int, iso_code: int):
+    """指定された年・国の出場者リストを取得し、表示用に加工して返す。
 
     Args:
         year (int): 出場者データを取得する対象の年。
+        iso_code (int): 対象国のISOコード。
 
     Returns:
-        Response: 指定国の出場者リストを含むHTMLテンプレートのレンダリング結果。
+        list[dict]: 加工・ソート済みの出場者リスト。
+
+    Raises:
+        Exception: Supabaseからの取得に失敗した場合。
 
     Note:
-        - URLの最後の要素から国名（例: "japan", "korea"）を取得し、該当するISOコードを割り当てる。
         - 単一国籍の出場者だけでなく、複数国籍チームの中に該当国のメンバーがいる場合もリストに含める。
         - 出場者名は大文字に変換され、カテゴリ名やチーム判定などの加工を行う。
         - 出場者リストはキャンセル状況、カテゴリ、ワイルドカード、ランキング、GBBシードの有無でソートされる。
-        - レンダリングするテンプレートは国名に応じて動的に決定される（例: "common/japan.html"）。
     """
-    # URLから国名を取得
-    url = request.path
-    country_name = url.split("/")[-1]  # 最後の要素が国名
-
-    # 対象国を増やす予定はないので、ハードコーディング
-    if country_name == "japan":
-        iso_code = 392
-    if country_name == "korea":
-        iso_code = 410
-
     # 出場者データを取得
-    try:
-        participants_data = supabase_service.get_data(
-            table="Participant",
-            columns=[
-                "id",
-                "name",
-                "category",
-                "ticket_class",
-                "is_cancelled",
-                "iso_code",
-            ],
-            join_tables={
-                "Category": ["id", "name", "is_team"],
-                "ParticipantMember": ["name"],
-                "Country": ["iso_code", "iso_alpha2"],
-            },
-            filters={
-                "year": year,
-                "iso_code": iso_code,
-            },
-            raise_error=True,
-        )
-        # 複数か国のチームも調べる
-        multi_country_team_data = supabase_service.get_data(
-            table="Participant",
-            columns=["id", "name", "category", "ticket_class", "is_cancelled"],
-            join_tables={
-                "Category": ["id", "name", "is_team"],
-                "ParticipantMember": ["name", "iso_code", "Country(iso_alpha2)"],
-                "Country": ["iso_code"],
-            },
-            filters={
-                "year": year,
-                "iso_code": MULTI_COUNTRY_TEAM_ISO_CODE,
-            },
-            raise_error=True,
-        )
-    except Exception:
-        abort(500)
+    participants_data = supabase_service.get_data(
+        table="Participant",
+        columns=[
+            "id",
+            "name",
+            "category",
+            "ticket_class",
+            "is_cancelled",
+            "iso_code",
+        ],
+        join_tables={
+            "Category": ["id", "name", "is_team"],
+            "ParticipantMember": ["name"],
+            "Country": ["iso_code", "iso_alpha2"],
+        },
+        filters={
+            "year": year,
+            "iso_code": iso_code,
+        },
+        raise_error=True,
+    )
+    # 複数か国のチームも調べる
+    multi_country_team_data = supabase_service.get_data(
+        table="Participant",
+        columns=["id", "name", "category", "ticket_class", "is_cancelled"],
+        join_tables={
+            "Category": ["id", "name", "is_team"],
+            "ParticipantMember": ["name", "iso_code", "Country(iso_alpha2)"],
+            "Country": ["iso_code"],
+        },
+        filters={
+            "year": year,
+            "iso_code": MULTI_COUNTRY_TEAM_ISO_CODE,
+        },
+        raise_error=True,
+    )
 
     # 探している国籍のチームだった場合、そのチームを追加
     for team in multi_country_team_data:
@@ -286,10 +321,69 @@ def participants_country_specific_view(year: int):
         )
     )
 
+    return participants_data
+
+
+def country_participants_view(
+    year: int, iso_alpha2: str, template_name: str = "common/country.html"
+):
+    """ISO alpha-2コードで指定された国の出場者ページを表示するビュー関数。
+
+    Args:
+        year (int): 出場者データを取得する対象の年。
+        iso_alpha2 (str): 対象国のISO 3166-1 alpha-2コード（例: "jp", "fr"）。
+        template_name (str, optional): レンダリングするテンプレート。
+
+    Returns:
+        Response: 指定国の出場者リストを含むHTMLテンプレートのレンダリング結果。
+            存在しない年度・国コードの場合は404。
+
+    Note:
+        /japan, /korea はこのビューのエイリアスで、従来のURL・タイトル
+        （「日本代表」など）を保つため専用のテンプレートを指定する。
+    """
+    # 年度の正当性チェック
+    available_years = get_available_years()
+    if year not in available_years:
+        abort(404)
+
+    # Countryテーブルのiso_alpha2は小文字で保存されている
+    iso_alpha2 = iso_alpha2.lower()
+    if len(iso_alpha2) != 2:
+        abort(404)
+
+    language = get_validated_language(session)
+
+    try:
+        country_data = supabase_service.get_data(
+            table="Country",
+            columns=["iso_code", "names", "iso_alpha2"],
+            filters={"iso_alpha2": iso_alpha2},
+            raise_error=True,
+        )
+    except Exception:
+        abort(500)
+
+    if not country_data:
+        abort(404)
+
+    country = country_data[0]
+
+    # 出場者未定枠・複数国籍チームは国として扱わない
+    if country["iso_code"] in (0, MULTI_COUNTRY_TEAM_ISO_CODE):
+        abort(404)
+
+    try:
+        participants_data = get_country_participants(year, country["iso_code"])
+    except Exception:
+        abort(500)
+
     context = {
         "participants": participants_data,
+        "country_name": country["names"].get(language, country["names"].get("en")),
+        "iso_alpha2": iso_alpha2,
     }
-    return render_template(f"common/{country_name}.html", **context)
+    return render_participants_template(template_name, **context)
 
 
 # MARK: 辞退者
@@ -343,4 +437,4 @@ def cancels_view(year: int):
     context = {
         "cancels": cancels_data,
     }
-    return render_template("common/cancels.html", **context)
+    return render_participants_template("common/cancels.html", **context)